@@ -7,7 +7,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"net"
 
 	"github.com/mdlayher/netx/eui64"
 )
@@ -20,15 +19,9 @@ var (
 func main() {
 	flag.Parse()
 
-	// IP flag required for both operations.
-	ip := net.ParseIP(*ipFlag)
-	if ip == nil {
-		log.Fatalf("invalid IP address: %s", *ipFlag)
-	}
-
 	// Attempt to parse prefix and MAC address from an IPv6 address.
-	if *ipFlag != "" && *macFlag == "" {
-		prefix, mac, err := eui64.ParseIP(ip)
+	if *macFlag == "" {
+		prefix, mac, err := eui64.ParseIPString(*ipFlag)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -38,16 +31,10 @@ func main() {
 	}
 
 	// Attempt to parse IPv6 address from IPv6 prefix and MAC address.
-
-	mac, err := net.ParseMAC(*macFlag)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	outIP, err := eui64.ParseMAC(ip, mac)
+	ip, err := eui64.ParseMACString(*ipFlag, *macFlag)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Printf("IP: %s\n", outIP)
+	fmt.Printf("IP: %s\n", ip)
 }
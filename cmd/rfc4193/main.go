@@ -13,20 +13,38 @@ import (
 	"github.com/mdlayher/netx/rfc4193"
 )
 
+var (
+	nFlag      = flag.Int("n", 1, "number of unique prefixes to generate using random seeds")
+	prefixFlag rfc4193.PrefixValue
+)
+
 func main() {
+	flag.Var(&prefixFlag, "prefix", "RFC4193 prefix to parse and describe, which may also be passed as an argument")
 	flag.Parse()
 	ll := log.New(os.Stderr, "", 0)
 
 	// If an argument is passed, parse it as a RFC4193 prefix.
 	if s := flag.Arg(0); s != "" {
-		p, err := rfc4193.Parse(s)
-		if err != nil {
+		if err := prefixFlag.Set(s); err != nil {
 			ll.Fatalf("failed to parse: %v", err)
 		}
+	}
 
-		size, _ := p.IPNet().Mask.Size()
-		fmt.Printf("local: %v, global ID: %#0x, subnet ID: %#04x, prefix: /%d\n",
-			p.Local, p.GlobalID, p.SubnetID, size)
+	if p := prefixFlag.Prefix; p != nil {
+		fmt.Println(rfc4193.Describe(p))
+		return
+	}
+
+	// Multiple prefixes must be unique, so a MAC address seed is not useful.
+	if *nFlag != 1 {
+		ps, err := rfc4193.GenerateN(*nFlag)
+		if err != nil {
+			ll.Fatalf("failed to generate RFC4193 prefixes: %v", err)
+		}
+
+		for _, p := range ps {
+			fmt.Println(p)
+		}
 		return
 	}
 
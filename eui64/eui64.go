@@ -3,33 +3,68 @@
 package eui64
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"net"
+	"net/netip"
 )
 
 // Possible errors due to bad input.
 var (
 	errInvalidIP     = errors.New("eui64: IP must be an IPv6 address")
+	errInvalidAddr   = errors.New("eui64: net.Addr must be a *net.TCPAddr, *net.UDPAddr, or *net.IPAddr")
 	errInvalidMAC    = errors.New("eui64: MAC address must be in EUI-48 or EUI-64 form")
+	errInfiniBandMAC = errors.New("eui64: 20-byte IP over InfiniBand link-layer addresses are not supported")
+	errPrefixScope   = errors.New("eui64: prefix must be a Global Unicast or Unique Local IPv6 prefix")
 	errInvalidPrefix = errors.New("eui64: prefix must be an IPv6 address prefix of /64 or less")
+	errZeroMAC       = errors.New("eui64: MAC address must not be all zeroes")
+	errBroadcastMAC  = errors.New("eui64: MAC address must not be the broadcast address")
+	errHostBits      = errors.New("eui64: prefix must not have any host bits set")
+	errInvalidDUID   = errors.New("eui64: DUID is too short or has a link-layer address of unexpected length")
+	errInvalidLLAddr = errors.New("eui64: client link-layer address option is too short or has an address of unexpected length")
+	errUnsupported   = errors.New("eui64: unsupported")
+	errNotDerived    = errors.New("eui64: interface identifier is not derived from an EUI-48 or EUI-64 MAC address")
+	errShortBuffer   = errors.New("eui64: buffer is too short")
 )
 
 // ParseIP parses an input IPv6 address to retrieve its IPv6 address prefix and
 // EUI-48 or EUI-64 MAC address. ip must be an IPv6 address or an error is
 // returned.
+//
+// ParseIP is the inverse of ParseMAC: only the "universal/local (U/L)" bit of
+// the interface identifier is inverted, so any MAC address passed to ParseMAC,
+// including a multicast or locally-administered address, is recovered
+// unchanged.
 func ParseIP(ip net.IP) (net.IP, net.HardwareAddr, error) {
-	if !isIPv6Addr(ip) {
-		return nil, nil, errInvalidIP
+	prefix := make(net.IP, net.IPv6len)
+	mac := make(net.HardwareAddr, 8)
+
+	n, err := ParseIPInto(ip, prefix, mac)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Prefix is first 8 bytes of IPv6 address.
-	prefix := make(net.IP, 16)
-	copy(prefix[0:8], ip[0:8])
+	return prefix, mac[:n], nil
+}
+
+// ParseIPInto is like ParseIP, but writes the IPv6 address prefix into the
+// first 16 bytes of prefix and the MAC address into the first bytes of mac
+// rather than allocating, for callers which parse many addresses. It returns
+// the length of the MAC address: 6 for EUI-48 or 8 for EUI-64.
+//
+// prefix must be at least 16 bytes and mac must be large enough for the
+// parsed MAC address, or an error is returned. A mac of 8 bytes is always
+// sufficient. ip is not modified.
+func ParseIPInto(ip net.IP, prefix, mac []byte) (int, error) {
+	if !isIPv6Addr(ip) {
+		return 0, errInvalidIP
+	}
 
 	// If IP address contains bytes 0xff and 0xfe adjacent in the middle
 	// of the MAC address section, these bytes must be removed to parse
 	// a EUI-48 hardware address.
-	isEUI48 := ip[11] == 0xff && ip[12] == 0xfe
+	isEUI48 := hasEUI48Marker(ip[8:16])
 
 	// MAC address length is determined by whether address is EUI-48 or EUI-64.
 	macLen := 8
@@ -37,7 +72,15 @@ func ParseIP(ip net.IP) (net.IP, net.HardwareAddr, error) {
 		macLen = 6
 	}
 
-	mac := make(net.HardwareAddr, macLen)
+	if len(prefix) < net.IPv6len || len(mac) < macLen {
+		return 0, errShortBuffer
+	}
+
+	// Prefix is first 8 bytes of IPv6 address.
+	copy(prefix[0:8], ip[0:8])
+	for i := 8; i < net.IPv6len; i++ {
+		prefix[i] = 0
+	}
 
 	if isEUI48 {
 		// Copy bytes preceeding and succeeding 0xff and 0xfe into MAC.
@@ -53,7 +96,268 @@ func ParseIP(ip net.IP) (net.IP, net.HardwareAddr, error) {
 	// information.
 	mac[0] ^= 0x02
 
-	return prefix, mac, nil
+	return macLen, nil
+}
+
+// Forms parses the MAC address embedded in an input IPv6 address as with
+// ParseIP, and returns both its EUI-48 and EUI-64 forms. If the interface
+// identifier does not contain the 0xff and 0xfe bytes which mark an embedded
+// EUI-48 address, eui48 is nil and only eui64 is returned. ip must be an IPv6
+// address or an error is returned.
+func Forms(ip net.IP) (eui48, eui64 net.HardwareAddr, err error) {
+	_, mac, err := ParseIP(ip)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(mac) == 8 {
+		return nil, mac, nil
+	}
+
+	eui64, err = EUI48ToEUI64(mac)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mac, eui64, nil
+}
+
+// EUI48ToEUI64 converts an EUI-48 MAC address to its EUI-64 form by inserting
+// the bytes 0xff and 0xfe between its first three and last three bytes. Unlike
+// ParseMAC, the "universal/local (U/L)" bit is not modified. mac must be in
+// EUI-48 form or an error is returned.
+func EUI48ToEUI64(mac net.HardwareAddr) (net.HardwareAddr, error) {
+	if len(mac) != 6 {
+		return nil, errInvalidMAC
+	}
+
+	out := make(net.HardwareAddr, 8)
+	copy(out[0:3], mac[0:3])
+	out[3] = 0xff
+	out[4] = 0xfe
+	copy(out[5:8], mac[3:6])
+
+	return out, nil
+}
+
+// BuildEUI64 assembles an EUI-64 from a 24-bit organizationally unique
+// identifier (OUI) and a 40-bit extension identifier, as assigned by a
+// manufacturer, by concatenating oui and ext. The result is a native EUI-64
+// rather than one derived from an EUI-48 MAC address, so the bytes 0xff and
+// 0xfe are not inserted and no bits are modified.
+//
+// The IEEE reserves extension identifiers beginning with 0xff 0xfe for
+// EUI-64s derived from EUI-48s, but BuildEUI64 does not reject them.
+func BuildEUI64(oui [3]byte, ext [5]byte) net.HardwareAddr {
+	out := make(net.HardwareAddr, 8)
+	copy(out[0:3], oui[:])
+	copy(out[3:8], ext[:])
+
+	return out
+}
+
+// Canonicalize normalizes an input IPv6 address by splitting it into its
+// prefix and MAC address with ParseIP and rebuilding it with ParseMAC,
+// producing a new 16-byte net.IP suitable for comparison and storage. Its
+// String method returns the canonical text form described in RFC 5952, with
+// lowercase hexadecimal digits and the longest run of zeroes compressed.
+//
+// The interface identifier of ip must be self-consistent: either it embeds an
+// EUI-48 address, or its "universal/local (U/L)" bit is set as it would be by
+// deriving it from a universally administered EUI-64 identifier. Otherwise,
+// such as for a temporary or opaque identifier, an error is returned. ip must
+// be an IPv6 address or an error is returned.
+func Canonicalize(ip net.IP) (net.IP, error) {
+	prefix, mac, err := ParseIP(ip)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIID(ip.To16()[8:16]); err != nil {
+		return nil, err
+	}
+
+	return ParseMAC(prefix, mac)
+}
+
+// VerifySplit reports whether ip, split at prefixLen as by Split, has an
+// interface identifier from which a MAC address can be trusted, such as when
+// ip and prefixLen are provided by an external source. This is the case when
+// the low 64 bits of ip appear to be derived from an EUI-48 or EUI-64 MAC
+// address, as reported by Classify, which is neither the all-zeroes nor the
+// broadcast (all-ones) address.
+//
+// ip must be an IPv6 address and prefixLen must be between 0 and 64, so that
+// the low 64 bits of ip are all interface identifier bits, or an error is
+// returned.
+func VerifySplit(ip net.IP, prefixLen int) (bool, error) {
+	if !isIPv6Addr(ip) {
+		return false, errInvalidIP
+	}
+	if prefixLen < 0 || prefixLen > 64 {
+		return false, errInvalidPrefix
+	}
+
+	if classify(ip[8:16]) == RandomOrOpaque {
+		return false, nil
+	}
+
+	_, mac, err := ParseIP(ip)
+	if err != nil {
+		return false, err
+	}
+
+	return !isAllZeroes(mac) && !isAllOnes(mac), nil
+}
+
+// ParseIPString is like ParseIP, but parses the IPv6 address from the string
+// s and returns the IPv6 prefix and MAC address in their canonical string
+// forms, such as "fe80::" and "00:12:7f:eb:6b:40".
+func ParseIPString(s string) (prefix, mac string, err error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "", "", fmt.Errorf("eui64: invalid IP address: %q", s)
+	}
+
+	p, m, err := ParseIP(ip)
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.String(), m.String(), nil
+}
+
+// ParseAddr is like ParseIP, but extracts the IPv6 address from addr, which
+// must be a *net.TCPAddr, *net.UDPAddr, or *net.IPAddr. Any other net.Addr,
+// such as a *net.UnixAddr, does not contain an IP address and an error is
+// returned, as it is for a nil pointer of one of the supported types.
+func ParseAddr(addr net.Addr) (net.IP, net.HardwareAddr, error) {
+	var ip net.IP
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		if addr == nil {
+			return nil, nil, errInvalidAddr
+		}
+		ip = addr.IP
+	case *net.UDPAddr:
+		if addr == nil {
+			return nil, nil, errInvalidAddr
+		}
+		ip = addr.IP
+	case *net.IPAddr:
+		if addr == nil {
+			return nil, nil, errInvalidAddr
+		}
+		ip = addr.IP
+	default:
+		return nil, nil, errInvalidAddr
+	}
+
+	return ParseIP(ip)
+}
+
+// DUID types and hardware types used by MACFromDUID and MACFromClientLLAddr.
+const (
+	duidLLT = 1
+	duidLL  = 3
+
+	hwEthernet = 1
+	hwEUI64    = 27
+)
+
+// ParseMACString is like ParseMAC, but parses the IPv6 prefix and MAC address
+// from strings and returns the IPv6 address in its canonical string form, such
+// as "fe80::212:7fff:feeb:6b40". mac may use any format accepted by
+// net.ParseMAC.
+func ParseMACString(prefix, mac string) (string, error) {
+	p := net.ParseIP(prefix)
+	if p == nil {
+		return "", fmt.Errorf("eui64: invalid IP prefix: %q", prefix)
+	}
+
+	m, err := net.ParseMAC(mac)
+	if err != nil {
+		return "", fmt.Errorf("eui64: invalid MAC address %q: %w", mac, err)
+	}
+
+	ip, err := ParseMAC(p, m)
+	if err != nil {
+		return "", err
+	}
+
+	return ip.String(), nil
+}
+
+// MACFromDUID extracts the EUI-48 or EUI-64 MAC address embedded in a DHCPv6
+// DUID, as described in RFC 8415, section 11. The returned MAC address can be
+// passed to ParseMAC to derive an IPv6 address.
+//
+// Only DUID-LLT (type 1) and DUID-LL (type 3) with a hardware type of Ethernet
+// (1) or EUI-64 (27) are supported, as other DUID types do not embed a MAC
+// address. An error is returned for any other DUID type or hardware type.
+func MACFromDUID(duid []byte) (net.HardwareAddr, error) {
+	if len(duid) < 4 {
+		return nil, errInvalidDUID
+	}
+
+	var addr []byte
+	switch typ := binary.BigEndian.Uint16(duid[0:2]); typ {
+	case duidLLT:
+		// Type, hardware type, and 4 byte time precede the address.
+		if len(duid) < 8 {
+			return nil, errInvalidDUID
+		}
+		addr = duid[8:]
+	case duidLL:
+		// Type and hardware type precede the address.
+		addr = duid[4:]
+	default:
+		return nil, fmt.Errorf("%w DUID type %d, only DUID-LLT (1) and DUID-LL (3) are supported",
+			errUnsupported, typ)
+	}
+
+	return hardwareAddr(binary.BigEndian.Uint16(duid[2:4]), addr, "DUID", errInvalidDUID)
+}
+
+// MACFromClientLLAddr extracts the EUI-48 or EUI-64 MAC address from the
+// payload of a DHCPv6 Client Link-Layer Address option (option 79), as
+// described in RFC 6939, section 4: a 2-byte hardware type followed by the
+// link-layer address. opt must not include the option code and length. The
+// returned MAC address can be passed to ParseMAC to derive an IPv6 address.
+//
+// Only a hardware type of Ethernet (1) or EUI-64 (27) is supported, and an
+// error is returned for any other hardware type or if the length of the
+// address does not match its hardware type.
+func MACFromClientLLAddr(opt []byte) (net.HardwareAddr, error) {
+	if len(opt) < 2 {
+		return nil, errInvalidLLAddr
+	}
+
+	return hardwareAddr(binary.BigEndian.Uint16(opt[0:2]), opt[2:], "client link-layer address", errInvalidLLAddr)
+}
+
+// hardwareAddr validates the link-layer address addr of hardware type hw from
+// the DHCPv6 structure described by what, returning errLength if addr is of
+// the wrong length for hw.
+func hardwareAddr(hw uint16, addr []byte, what string, errLength error) (net.HardwareAddr, error) {
+	var want int
+	switch hw {
+	case hwEthernet:
+		want = 6
+	case hwEUI64:
+		want = 8
+	default:
+		return nil, fmt.Errorf("%w %s hardware type %d, only Ethernet (1) and EUI-64 (27) are supported",
+			errUnsupported, what, hw)
+	}
+
+	if len(addr) != want {
+		return nil, errLength
+	}
+
+	// Copy to avoid aliasing the input.
+	mac := make(net.HardwareAddr, len(addr))
+	copy(mac, addr)
+	return mac, nil
 }
 
 // ParseMAC parses an input IPv6 address prefix and EUI-48 or EUI-64 MAC
@@ -62,25 +366,344 @@ func ParseIP(ip net.IP) (net.IP, net.HardwareAddr, error) {
 //
 // An error is returned if prefix is not an IPv6 address with only the first 64
 // bits or less set, or mac is not in EUI-48 or EUI-64 form.
+//
+// Only the "universal/local (U/L)" bit of the first octet of mac is inverted.
+// The "individual/group (I/G)" bit is preserved, so a multicast MAC address
+// such as 01:00:5e:00:00:01 is not considered invalid and produces an
+// interface identifier with that bit set.
 func ParseMAC(prefix net.IP, mac net.HardwareAddr) (net.IP, error) {
-	if !isIPv6Addr(prefix) {
-		return nil, errInvalidIP
+	if err := checkPrefixMAC(prefix, mac); err != nil {
+		return nil, err
 	}
 
-	// Prefix must be 64 bits or less in length, meaning the last 8
-	// bytes must be entirely zero.
-	if !isAllZeroes(prefix[8:16]) {
-		return nil, errInvalidPrefix
+	ip := make(net.IP, 16)
+	putIP(ip, prefix, mac)
+	return ip, nil
+}
+
+// ParseMACPrefix is a stricter, netip-native form of ParseMAC. prefix must be
+// a valid IPv6 prefix of /64 or less, and must be a network address with no
+// bits set beyond its prefix length, or an error is returned.
+//
+// ParseMAC only verifies that the last 64 bits of its prefix are zero, so a
+// prefix such as 2001:db8::1:0:0:0 intended as a /48 is accepted and produces
+// an address within a different /64. ParseMACPrefix rejects the equivalent
+// netip.Prefix 2001:db8:0:1::/48 because it is not equal to its Masked form.
+func ParseMACPrefix(prefix netip.Prefix, mac net.HardwareAddr) (netip.Addr, error) {
+	addr := prefix.Addr()
+	if !prefix.IsValid() || !addr.Is6() || addr.Is4In6() {
+		return netip.Addr{}, errInvalidIP
+	}
+	if prefix.Bits() > 64 {
+		return netip.Addr{}, errInvalidPrefix
+	}
+	if prefix != prefix.Masked() {
+		return netip.Addr{}, errHostBits
+	}
+
+	// MAC must be in EUI-48 or EUI64 form.
+	if len(mac) != 6 && len(mac) != 8 {
+		return netip.Addr{}, errInvalidMAC
+	}
+
+	ip := addr.As16()
+	putIP(ip[:], ip[:8], mac)
+	return netip.AddrFrom16(ip), nil
+}
+
+// Split is like ParseIP, but splits addr at an arbitrary prefix length of 64
+// bits or less, such as when the routing prefix is shorter than /64. It
+// returns the network prefix of addr at prefixLen and the EUI-48 or EUI-64 MAC
+// address derived from the low 64 bits of addr. Any bits between prefixLen and
+// the low 64 bits, such as a subnet ID in a /48, are in neither result.
+//
+// The low 64 bits of addr must be EUI-64-shaped: they must embed an EUI-48
+// address, or have the "universal/local (U/L)" bit set as it would be by
+// deriving them from a universally administered EUI-64 identifier, or an
+// error is returned. addr must be an IPv6 address and prefixLen must be
+// between 0 and 64, or an error is returned.
+func Split(addr netip.Addr, prefixLen int) (netip.Prefix, net.HardwareAddr, error) {
+	if !addr.Is6() || addr.Is4In6() {
+		return netip.Prefix{}, nil, errInvalidIP
+	}
+	if prefixLen < 0 || prefixLen > 64 {
+		return netip.Prefix{}, nil, errInvalidPrefix
+	}
+
+	ip := addr.As16()
+	if err := checkIID(ip[8:16]); err != nil {
+		return netip.Prefix{}, nil, err
 	}
 
+	_, mac, err := ParseIP(ip[:])
+	if err != nil {
+		return netip.Prefix{}, nil, err
+	}
+
+	return netip.PrefixFrom(addr.WithZone(""), prefixLen).Masked(), mac, nil
+}
+
+// ParseMACMulti is like ParseMAC, but produces one IPv6 address for mac within
+// each of prefixes, such as for a device attached to multiple networks. If any
+// prefix is invalid, an error identifying the index of the first invalid
+// prefix is returned.
+func ParseMACMulti(prefixes []net.IP, mac net.HardwareAddr) ([]net.IP, error) {
 	// MAC must be in EUI-48 or EUI64 form.
 	if len(mac) != 6 && len(mac) != 8 {
 		return nil, errInvalidMAC
 	}
 
-	// Copy prefix directly into first 8 bytes of IP address.
+	ips := make([]net.IP, 0, len(prefixes))
+	for i, prefix := range prefixes {
+		ip, err := ParseMAC(prefix, mac)
+		if err != nil {
+			return nil, fmt.Errorf("eui64: prefix at index %d: %w", i, err)
+		}
+
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+// linkLocalPrefix is the IPv6 Link-Local Unicast prefix fe80::/64.
+var linkLocalPrefix = net.IP{0: 0xfe, 1: 0x80, 15: 0x00}
+
+// InterfaceAddrs produces the IPv6 addresses derived from mac for a network
+// interface: the Link-Local Unicast address within fe80::/64, followed by one
+// address within each of prefixes, in order, such as when configuring an
+// interface attached to several networks.
+//
+// mac is validated as by NormalizeMAC, so the all-zeroes and broadcast
+// addresses are rejected. Each prefix must be valid for ParseMAC and be a
+// Global Unicast or Unique Local prefix, as the link-local address is
+// produced automatically. If any prefix is invalid, an error identifying the
+// index of the first invalid prefix is returned.
+func InterfaceAddrs(mac net.HardwareAddr, prefixes []net.IP) ([]net.IP, error) {
+	mac, err := NormalizeMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+
+	ll, err := ParseMAC(linkLocalPrefix, mac)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, 1+len(prefixes))
+	ips = append(ips, ll)
+
+	for i, prefix := range prefixes {
+		ip, err := ParseMAC(prefix, mac)
+		if err != nil {
+			return nil, fmt.Errorf("eui64: prefix at index %d: %w", i, err)
+		}
+
+		addr, _ := netip.AddrFromSlice(ip)
+		if !IsGlobalUnicast(addr) && !IsUniqueLocal(addr) {
+			return nil, fmt.Errorf("eui64: prefix at index %d: %w", i, errPrefixScope)
+		}
+
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+// ParseShortAddr produces an IPv6 address for an IEEE 802.15.4 node with a
+// 16-bit short address, as used by 6LoWPAN and described in RFC 4944, section
+// 6. The interface identifier is formed from the PAN ID pan with its
+// "universal/local (U/L)" bit cleared, followed by 00ff:fe00 and short, and is
+// combined with the first 64 bits of prefix.
+//
+// An error is returned if prefix is not an IPv6 address with only the first 64
+// bits or less set.
+func ParseShortAddr(prefix net.IP, pan, short uint16) (net.IP, error) {
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip[0:8], prefix[0:8])
+
+	binary.BigEndian.PutUint16(ip[8:10], pan)
+	ip[8] &^= 0x02
+	ip[11] = 0xff
+	ip[12] = 0xfe
+	binary.BigEndian.PutUint16(ip[14:16], short)
+
+	return ip, nil
+}
+
+// NormalizeMAC validates mac, such as one produced by net.ParseMAC, and returns
+// it in canonical form for use with the other functions in this package. It
+// returns an error if mac is not in EUI-48 or EUI-64 form, including the
+// 20-byte IP over InfiniBand addresses which net.ParseMAC also accepts, or if
+// mac is the all-zeroes or broadcast (all-ones) address.
+//
+// An EUI-64 which encapsulates an EUI-48, containing the bytes 0xff and 0xfe
+// in its middle, is returned in EUI-48 form, as ParseIP would recover it.
+// Otherwise, the returned net.HardwareAddr is a copy of mac.
+func NormalizeMAC(mac net.HardwareAddr) (net.HardwareAddr, error) {
+	switch {
+	case len(mac) == 20:
+		return nil, errInfiniBandMAC
+	case len(mac) != 6 && len(mac) != 8:
+		return nil, errInvalidMAC
+	case isAllZeroes(mac):
+		return nil, errZeroMAC
+	case isAllOnes(mac):
+		return nil, errBroadcastMAC
+	}
+
+	if len(mac) == 8 && hasEUI48Marker(mac) {
+		out := make(net.HardwareAddr, 6)
+		copy(out[0:3], mac[0:3])
+		copy(out[3:6], mac[5:8])
+		return out, nil
+	}
+
+	out := make(net.HardwareAddr, len(mac))
+	copy(out, mac)
+	return out, nil
+}
+
+// ParseMACStrict is like ParseMAC, but also returns an error if mac is the
+// all-zeroes or broadcast (all-ones) address. Such a MAC address is typically
+// reported by an interface with no real hardware address and produces a
+// meaningless IPv6 address.
+func ParseMACStrict(prefix net.IP, mac net.HardwareAddr) (net.IP, error) {
+	if err := checkPrefixMAC(prefix, mac); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isAllZeroes(mac):
+		return nil, errZeroMAC
+	case isAllOnes(mac):
+		return nil, errBroadcastMAC
+	}
+
+	return ParseMAC(prefix, mac)
+}
+
+// AppendIP appends the raw 16 bytes of the IPv6 address produced by ParseMAC
+// for prefix and mac to dst, returning the extended buffer. It does not
+// allocate if dst has sufficient capacity, making it suitable for encoding
+// addresses directly into packets.
+//
+// The same validation as ParseMAC applies. If an error is returned, dst is
+// returned unmodified.
+func AppendIP(dst []byte, prefix net.IP, mac net.HardwareAddr) ([]byte, error) {
+	if err := checkPrefixMAC(prefix, mac); err != nil {
+		return dst, err
+	}
+
+	n := len(dst)
+	dst = append(dst, make([]byte, 16)...)
+	putIP(dst[n:], prefix, mac)
+	return dst, nil
+}
+
+// CandidateMACs returns the MAC addresses which may have produced the
+// interface identifier iid, for devices which do not conform to RFC 4291 by
+// omitting the inversion of the "universal/local (U/L)" bit. If iid contains
+// the 0xff and 0xfe bytes which mark an embedded EUI-48 address, both
+// candidates are in EUI-48 form, and otherwise both are in EUI-64 form.
+//
+// The first candidate is the RFC-conformant MAC address, as returned by
+// ParseIP, and the second is the same MAC address with its U/L bit unchanged
+// from iid.
+func CandidateMACs(iid [8]byte) []net.HardwareAddr {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip[8:16], iid[:])
+
+	// ip is always a valid IPv6 address.
+	_, mac, _ := ParseIP(ip)
+
+	raw := make(net.HardwareAddr, len(mac))
+	copy(raw, mac)
+	raw[0] ^= 0x02
+
+	return []net.HardwareAddr{mac, raw}
+}
+
+// IIDUint64 returns the interface identifier of an IPv6 address, the low 64
+// bits of ip, as a big-endian uint64. The identifier is returned as-is, with
+// no modification to the "universal/local (U/L)" bit. ip must be an IPv6
+// address or an error is returned.
+func IIDUint64(ip net.IP) (uint64, error) {
+	if !isIPv6Addr(ip) {
+		return 0, errInvalidIP
+	}
+
+	return binary.BigEndian.Uint64(ip[8:16]), nil
+}
+
+// IPFromIIDUint64 is the inverse of IIDUint64. It produces an IPv6 address by
+// combining the first 64 bits of prefix with the big-endian interface
+// identifier iid, with no MAC address derivation and no modification to the
+// "universal/local (U/L)" bit. This is also useful for manually-assigned
+// addresses such as the Subnet-Router anycast address (iid 0, RFC 4291,
+// section 2.6.1) or a router at iid 1, such as fe80::1.
+//
+// An error is returned if prefix is not an IPv6 address with only the first 64
+// bits or less set.
+func IPFromIIDUint64(prefix net.IP, iid uint64) (net.IP, error) {
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
 	ip := make(net.IP, 16)
 	copy(ip[0:8], prefix[0:8])
+	binary.BigEndian.PutUint64(ip[8:16], iid)
+	return ip, nil
+}
+
+// checkIID verifies that the 8-byte interface identifier iid appears to be
+// derived from an EUI-48 or EUI-64 MAC address, as reported by classify.
+func checkIID(iid []byte) error {
+	if classify(iid) == RandomOrOpaque {
+		return errNotDerived
+	}
+
+	return nil
+}
+
+// checkPrefixMAC verifies that prefix and mac are suitable for ParseMAC.
+func checkPrefixMAC(prefix net.IP, mac net.HardwareAddr) error {
+	if err := checkPrefix(prefix); err != nil {
+		return err
+	}
+
+	// MAC must be in EUI-48 or EUI64 form.
+	if len(mac) != 6 && len(mac) != 8 {
+		return errInvalidMAC
+	}
+
+	return nil
+}
+
+// checkPrefix verifies that prefix is an IPv6 prefix of /64 or less.
+func checkPrefix(prefix net.IP) error {
+	if !isIPv6Addr(prefix) {
+		return errInvalidIP
+	}
+
+	// Prefix must be 64 bits or less in length, meaning the last 8
+	// bytes must be entirely zero.
+	if !isAllZeroes(prefix[8:16]) {
+		return errInvalidPrefix
+	}
+
+	return nil
+}
+
+// putIP writes the IPv6 address for prefix and mac into the 16 byte slice ip.
+// prefix and mac must have been validated by checkPrefixMAC.
+func putIP(ip []byte, prefix net.IP, mac net.HardwareAddr) {
+	// Copy prefix directly into first 8 bytes of IP address.
+	copy(ip[0:8], prefix[0:8])
 
 	// Flip 7th bit from left on the first byte of the MAC address, the
 	// "universal/local (U/L)" bit.  See RFC 4291, Section 2.5.1 for more
@@ -90,7 +713,7 @@ func ParseMAC(prefix net.IP, mac net.HardwareAddr) (net.IP, error) {
 	if len(mac) == 8 {
 		copy(ip[8:16], mac)
 		ip[8] ^= 0x02
-		return ip, nil
+		return
 	}
 
 	// If MAC is in EUI-48 form, split first three bytes and last three bytes,
@@ -100,8 +723,183 @@ func ParseMAC(prefix net.IP, mac net.HardwareAddr) (net.IP, error) {
 	ip[11] = 0xff
 	ip[12] = 0xfe
 	copy(ip[13:16], mac[3:6])
+}
 
-	return ip, nil
+// A Kind is a best-effort guess at the origin of an IPv6 address's interface
+// identifier.
+type Kind int
+
+// Possible Kind values.
+const (
+	_ Kind = iota
+
+	// EUI48Derived indicates an interface identifier produced from an EUI-48
+	// MAC address, as described in RFC 4291, Appendix A. The identifier
+	// contains the bytes 0xff and 0xfe inserted in the middle of the MAC.
+	EUI48Derived
+
+	// EUI64Derived indicates an interface identifier produced from a
+	// universally administered EUI-64 identifier, as described in RFC 4291,
+	// Section 2.5.1.
+	EUI64Derived
+
+	// RandomOrOpaque indicates an interface identifier which does not appear
+	// to be derived from a MAC address, such as a temporary address (RFC 4941)
+	// or a semantically opaque identifier (RFC 7217). This is a best-effort
+	// guess: a locally administered EUI-64 identifier is indistinguishable
+	// from a random one.
+	RandomOrOpaque
+)
+
+// String returns the name of a Kind.
+func (k Kind) String() string {
+	switch k {
+	case EUI48Derived:
+		return "EUI48Derived"
+	case EUI64Derived:
+		return "EUI64Derived"
+	case RandomOrOpaque:
+		return "RandomOrOpaque"
+	default:
+		return fmt.Sprintf("Kind(%d)", k)
+	}
+}
+
+// Classify inspects the interface identifier of an IPv6 address to guess its
+// origin. ip must be an IPv6 address or an error is returned.
+func Classify(ip net.IP) (Kind, error) {
+	if !isIPv6Addr(ip) {
+		return 0, errInvalidIP
+	}
+
+	return classify(ip[8:16]), nil
+}
+
+// classify guesses the Kind of the 8-byte interface identifier iid.
+func classify(iid []byte) Kind {
+	switch {
+	case hasEUI48Marker(iid):
+		return EUI48Derived
+	case iid[0]&0x02 != 0:
+		// In Modified EUI-64 format the U/L bit is inverted, so a set bit
+		// indicates a universally administered (and thus hardware) identifier.
+		return EUI64Derived
+	default:
+		return RandomOrOpaque
+	}
+}
+
+// IsPrivacyAddress reports whether ip appears to be a temporary address, as
+// described in RFC 4941, whose interface identifier is random rather than
+// derived from a MAC address: it lacks the 0xff and 0xfe bytes of an EUI-48
+// derived identifier, and its universal/local bit indicates that it is not
+// universally administered. It returns false if ip is not an IPv6 address.
+//
+// This is a best-effort heuristic equivalent to Classify returning
+// RandomOrOpaque. A random identifier may coincidentally appear to be derived
+// from a MAC address, and stable but opaque identifiers, such as those
+// described in RFC 7217, are indistinguishable from temporary ones.
+func IsPrivacyAddress(ip net.IP) bool {
+	return isIPv6Addr(ip) && classify(ip[8:16]) == RandomOrOpaque
+}
+
+// An Inspection describes an IPv6 address and its interface identifier, as
+// produced by Inspect.
+type Inspection struct {
+	// Prefix is the IPv6 address prefix: the upper 64 bits of the address,
+	// with the interface identifier bits cleared.
+	Prefix net.IP
+
+	// MAC is the EUI-48 or EUI-64 MAC address recovered from the interface
+	// identifier, or nil if Kind is RandomOrOpaque.
+	MAC net.HardwareAddr
+
+	// Kind is a best-effort guess at the origin of the interface identifier,
+	// as reported by Classify.
+	Kind Kind
+
+	// Universal and Group report the state of the "universal/local (U/L)"
+	// and "individual/group (I/G)" bits of the MAC address from which the
+	// interface identifier would be derived, accounting for the inverted
+	// U/L bit of the Modified EUI-64 format. Universal is set for a
+	// universally administered MAC address and Group for a multicast one.
+	// Both are reported even if Kind is RandomOrOpaque, in which case they
+	// carry no meaning and Universal is always false.
+	Universal, Group bool
+
+	// LinkLocal, UniqueLocal, and GlobalUnicast report the scope of the
+	// address, as reported by IsLinkLocal, IsUniqueLocal, and
+	// IsGlobalUnicast. At most one is set.
+	LinkLocal, UniqueLocal, GlobalUnicast bool
+}
+
+// Inspect reports everything this package can determine about an IPv6
+// address in a single call, composing ParseIP, Classify, and the scope
+// predicates such as IsLinkLocal. ip must be an IPv6 address or an error is
+// returned.
+func Inspect(ip net.IP) (Inspection, error) {
+	prefix, mac, err := ParseIP(ip)
+	if err != nil {
+		return Inspection{}, err
+	}
+
+	var (
+		iid  = ip.To16()[8:16]
+		addr = netip.AddrFrom16(*(*[16]byte)(ip.To16()))
+		kind = classify(iid)
+	)
+
+	if kind == RandomOrOpaque {
+		mac = nil
+	}
+
+	return Inspection{
+		Prefix:        prefix,
+		MAC:           mac,
+		Kind:          kind,
+		Universal:     iid[0]&0x02 != 0,
+		Group:         iid[0]&0x01 != 0,
+		LinkLocal:     IsLinkLocal(addr),
+		UniqueLocal:   IsUniqueLocal(addr),
+		GlobalUnicast: IsGlobalUnicast(addr),
+	}, nil
+}
+
+// IsGlobalUnicast reports whether ip is an IPv6 Global Unicast address within
+// 2000::/3. A net.IP can be converted using netip.AddrFromSlice.
+func IsGlobalUnicast(ip netip.Addr) bool {
+	b, ok := as16(ip)
+	return ok && b[0]&0xe0 == 0x20
+}
+
+// IsUniqueLocal reports whether ip is an IPv6 Unique Local address within
+// fc00::/7. A net.IP can be converted using netip.AddrFromSlice.
+func IsUniqueLocal(ip netip.Addr) bool {
+	b, ok := as16(ip)
+	return ok && b[0]&0xfe == 0xfc
+}
+
+// IsLinkLocal reports whether ip is an IPv6 Link-Local Unicast address within
+// fe80::/10. A net.IP can be converted using netip.AddrFromSlice.
+func IsLinkLocal(ip netip.Addr) bool {
+	b, ok := as16(ip)
+	return ok && b[0] == 0xfe && b[1]&0xc0 == 0x80
+}
+
+// as16 returns the bytes of ip if it is an IPv6 address, excluding
+// IPv4-mapped IPv6 addresses.
+func as16(ip netip.Addr) ([16]byte, bool) {
+	if !ip.Is6() || ip.Is4In6() {
+		return [16]byte{}, false
+	}
+
+	return ip.As16(), true
+}
+
+// hasEUI48Marker reports whether the 8-byte interface identifier iid contains
+// the 0xff and 0xfe bytes used to expand an EUI-48 MAC address.
+func hasEUI48Marker(iid []byte) bool {
+	return iid[3] == 0xff && iid[4] == 0xfe
 }
 
 // isAllZeroes returns if a byte slice is entirely populated with byte 0.
@@ -115,6 +913,17 @@ func isAllZeroes(b []byte) bool {
 	return true
 }
 
+// isAllOnes returns if a byte slice is entirely populated with byte 0xff.
+func isAllOnes(b []byte) bool {
+	for i := 0; i < len(b); i++ {
+		if b[i] != 0xff {
+			return false
+		}
+	}
+
+	return true
+}
+
 // isIPv6Addr returns if an IP address is a valid IPv6 address.
 func isIPv6Addr(ip net.IP) bool {
 	if ip.To16() == nil {
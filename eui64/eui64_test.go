@@ -2,9 +2,13 @@ package eui64
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/netip"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -85,14 +89,1820 @@ func TestParseIP(t *testing.T) {
 	}
 }
 
+// TestForms verifies that Forms returns the EUI-48 and EUI-64 forms of the MAC
+// addresses embedded in input IPv6 addresses.
+func TestForms(t *testing.T) {
+	tests := []struct {
+		desc  string
+		ip    net.IP
+		eui48 net.HardwareAddr
+		eui64 net.HardwareAddr
+		err   error
+	}{
+		{
+			desc: "IPv4 address",
+			ip:   net.IPv4(192, 168, 1, 1),
+			err:  errInvalidIP,
+		},
+		{
+			desc:  "IPv6 EUI-64 MAC",
+			ip:    net.ParseIP("2001:db8::1"),
+			eui64: net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		},
+		{
+			desc:  "IPv6 EUI-48 MAC",
+			ip:    net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			eui48: net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			eui64: net.HardwareAddr{0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			eui48, eui64, err := Forms(tt.ip)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.eui48, eui48; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected EUI-48 MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := tt.eui64, eui64; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected EUI-64 MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestEUI48ToEUI64 verifies that EUI48ToEUI64 converts EUI-48 MAC addresses
+// without modifying the U/L bit.
+func TestEUI48ToEUI64(t *testing.T) {
+	tests := []struct {
+		desc string
+		mac  net.HardwareAddr
+		out  net.HardwareAddr
+		err  error
+	}{
+		{
+			desc: "nil MAC address",
+			err:  errInvalidMAC,
+		},
+		{
+			desc: "EUI-64 MAC address",
+			mac:  net.HardwareAddr{0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+			err:  errInvalidMAC,
+		},
+		{
+			desc: "EUI-48 MAC address",
+			mac:  net.HardwareAddr{0x02, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			out:  net.HardwareAddr{0x02, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out, err := EUI48ToEUI64(tt.mac)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.out, out; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestParseIPInto verifies that ParseIPInto writes the same output as ParseIP
+// into caller-provided buffers.
+func TestParseIPInto(t *testing.T) {
+	tests := []struct {
+		desc   string
+		ip     net.IP
+		prefix int
+		mac    int
+		err    error
+	}{
+		{
+			desc:   "IPv4 address",
+			ip:     net.IPv4(192, 168, 1, 1),
+			prefix: 16,
+			mac:    8,
+			err:    errInvalidIP,
+		},
+		{
+			desc:   "short prefix buffer",
+			ip:     net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			prefix: 8,
+			mac:    8,
+			err:    errShortBuffer,
+		},
+		{
+			desc:   "short MAC buffer for EUI-64",
+			ip:     net.ParseIP("2001:db8::1"),
+			prefix: 16,
+			mac:    6,
+			err:    errShortBuffer,
+		},
+		{
+			desc:   "EUI-48 with exact MAC buffer",
+			ip:     net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			prefix: 16,
+			mac:    6,
+		},
+		{
+			desc:   "EUI-64",
+			ip:     net.ParseIP("2001:db8::1"),
+			prefix: 16,
+			mac:    8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			origIP := make(net.IP, len(tt.ip))
+			copy(origIP, tt.ip)
+
+			// Buffers are reused with stale contents.
+			var (
+				prefix = bytes.Repeat([]byte{0xff}, tt.prefix)
+				mac    = bytes.Repeat([]byte{0xff}, tt.mac)
+			)
+
+			n, err := ParseIPInto(tt.ip, prefix, mac)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := origIP, tt.ip; !want.Equal(got) {
+				t.Fatalf("IP was modified:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			wantPrefix, wantMAC, err := ParseIP(tt.ip)
+			if err != nil {
+				t.Fatalf("failed to parse IP: %v", err)
+			}
+
+			if want, got := wantPrefix, net.IP(prefix); !want.Equal(got) {
+				t.Fatalf("unexpected IPv6 prefix:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := wantMAC, net.HardwareAddr(mac[:n]); !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestParseIPIntoAllocations verifies that ParseIPInto does not allocate.
+func TestParseIPIntoAllocations(t *testing.T) {
+	var (
+		ip     = net.ParseIP("fe80::212:7fff:feeb:6b40")
+		prefix = make([]byte, 16)
+		mac    = make([]byte, 8)
+	)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = ParseIPInto(ip, prefix, mac)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("unexpected allocations: %v", allocs)
+	}
+}
+
+// BenchmarkParseIP measures the cost of parsing an address with allocated
+// output.
+func BenchmarkParseIP(b *testing.B) {
+	ip := net.ParseIP("fe80::212:7fff:feeb:6b40")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := ParseIP(ip); err != nil {
+			b.Fatalf("failed to parse IP: %v", err)
+		}
+	}
+}
+
+// BenchmarkParseIPInto measures the cost of parsing an address into existing
+// buffers.
+func BenchmarkParseIPInto(b *testing.B) {
+	var (
+		ip     = net.ParseIP("fe80::212:7fff:feeb:6b40")
+		prefix = make([]byte, 16)
+		mac    = make([]byte, 8)
+	)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseIPInto(ip, prefix, mac); err != nil {
+			b.Fatalf("failed to parse IP: %v", err)
+		}
+	}
+}
+
+// TestParseAddr verifies that ParseAddr extracts IPv6 addresses from net.Addrs
+// which contain them and rejects all other net.Addrs.
+func TestParseAddr(t *testing.T) {
+	var (
+		ip     = net.ParseIP("fe80::212:7fff:feeb:6b40")
+		prefix = net.ParseIP("fe80::")
+		mac    = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+	)
+
+	tests := []struct {
+		desc string
+		addr net.Addr
+		err  error
+	}{
+		{
+			desc: "nil",
+			err:  errInvalidAddr,
+		},
+		{
+			desc: "UNIX",
+			addr: &net.UnixAddr{Name: "/tmp/foo.sock", Net: "unix"},
+			err:  errInvalidAddr,
+		},
+		{
+			desc: "nil TCP",
+			addr: (*net.TCPAddr)(nil),
+			err:  errInvalidAddr,
+		},
+		{
+			desc: "nil UDP",
+			addr: (*net.UDPAddr)(nil),
+			err:  errInvalidAddr,
+		},
+		{
+			desc: "nil IP",
+			addr: (*net.IPAddr)(nil),
+			err:  errInvalidAddr,
+		},
+		{
+			desc: "IPv4 TCP",
+			addr: &net.TCPAddr{IP: net.IPv4(192, 168, 1, 1), Port: 80},
+			err:  errInvalidIP,
+		},
+		{
+			desc: "TCP",
+			addr: &net.TCPAddr{IP: ip, Port: 80},
+		},
+		{
+			desc: "UDP",
+			addr: &net.UDPAddr{IP: ip, Port: 53, Zone: "eth0"},
+		},
+		{
+			desc: "IP",
+			addr: &net.IPAddr{IP: ip},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			gotPrefix, gotMAC, err := ParseAddr(tt.addr)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := prefix, gotPrefix; !want.Equal(got) {
+				t.Fatalf("unexpected IPv6 prefix:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := mac, gotMAC; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestBuildEUI64 verifies that BuildEUI64 assembles an EUI-64 from its OUI and
+// extension identifier without modifying any bits.
+func TestBuildEUI64(t *testing.T) {
+	tests := []struct {
+		desc string
+		oui  [3]byte
+		ext  [5]byte
+		out  net.HardwareAddr
+	}{
+		{
+			desc: "zero",
+			out:  net.HardwareAddr{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			desc: "native EUI-64",
+			oui:  [3]byte{0x00, 0x12, 0x7f},
+			ext:  [5]byte{0x01, 0x23, 0x45, 0x67, 0x89},
+			out:  net.HardwareAddr{0x00, 0x12, 0x7f, 0x01, 0x23, 0x45, 0x67, 0x89},
+		},
+		{
+			desc: "bits unmodified",
+			oui:  [3]byte{0x03, 0x12, 0x7f},
+			ext:  [5]byte{0xff, 0xfe, 0xeb, 0x6b, 0x40},
+			out:  net.HardwareAddr{0x03, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if want, got := tt.out, BuildEUI64(tt.oui, tt.ext); !bytes.Equal(want, got) {
+				t.Fatalf("unexpected EUI-64:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestCanonicalize verifies that Canonicalize produces canonical IPv6 addresses
+// with unmodified interface identifiers.
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		desc string
+		ip   net.IP
+		s    string
+		err  error
+	}{
+		{
+			desc: "nil IP address",
+			err:  errInvalidIP,
+		},
+		{
+			desc: "IPv4 address",
+			ip:   net.IPv4(192, 168, 1, 1),
+			err:  errInvalidIP,
+		},
+		{
+			desc: "IPv4-mapped IPv6 address",
+			ip:   net.ParseIP("::ffff:192.168.1.1"),
+			err:  errInvalidIP,
+		},
+		{
+			desc: "EUI-48 uppercase and expanded",
+			ip:   net.ParseIP("FE80:0000:0000:0000:0212:7FFF:FEEB:6B40"),
+			s:    "fe80::212:7fff:feeb:6b40",
+		},
+		{
+			desc: "random interface identifier",
+			ip:   net.ParseIP("2001:db8::1"),
+			err:  errNotDerived,
+		},
+		{
+			desc: "EUI-64 U/L bit cleared",
+			ip:   net.ParseIP("2001:db8::12:7f00:eb:6b40"),
+			err:  errNotDerived,
+		},
+		{
+			desc: "EUI-64",
+			ip:   net.ParseIP("2001:0DB8:0000:0000:0212:7F00:00EB:6B40"),
+			s:    "2001:db8::212:7f00:eb:6b40",
+		},
+		{
+			desc: "multicast EUI-48",
+			ip:   net.ParseIP("fe80::300:5eff:fe00:1"),
+			s:    "fe80::300:5eff:fe00:1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ip, err := Canonicalize(tt.ip)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.s, ip.String(); want != got {
+				t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := net.IPv6len, len(ip); want != got {
+				t.Fatalf("unexpected IPv6 address length:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := tt.ip, ip; !want.Equal(got) {
+				t.Fatalf("IPv6 address was modified:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestVerifySplit verifies that VerifySplit accepts only prefix lengths of /64
+// or less and interface identifiers derived from usable MAC addresses.
+func TestVerifySplit(t *testing.T) {
+	eui48 := net.ParseIP("2001:db8:1:2300:212:7fff:feeb:6b40")
+
+	tests := []struct {
+		desc      string
+		ip        net.IP
+		prefixLen int
+		ok        bool
+		err       error
+	}{
+		{
+			desc: "nil IP address",
+			err:  errInvalidIP,
+		},
+		{
+			desc: "IPv4 address",
+			ip:   net.IPv4(192, 168, 1, 1),
+			err:  errInvalidIP,
+		},
+		{
+			desc:      "negative prefix length",
+			ip:        eui48,
+			prefixLen: -1,
+			err:       errInvalidPrefix,
+		},
+		{
+			desc:      "/129 prefix",
+			ip:        eui48,
+			prefixLen: 129,
+			err:       errInvalidPrefix,
+		},
+		{
+			desc:      "/80 prefix",
+			ip:        eui48,
+			prefixLen: 80,
+			err:       errInvalidPrefix,
+		},
+		{
+			desc:      "/65 prefix",
+			ip:        eui48,
+			prefixLen: 65,
+			err:       errInvalidPrefix,
+		},
+		{
+			desc:      "random",
+			ip:        net.ParseIP("2001:db8::d5e3:7953:13eb:22e8"),
+			prefixLen: 64,
+		},
+		{
+			desc:      "all zeroes MAC",
+			ip:        net.ParseIP("2001:db8::200:ff:fe00:0"),
+			prefixLen: 64,
+		},
+		{
+			desc:      "broadcast MAC",
+			ip:        net.ParseIP("2001:db8::fdff:ffff:feff:ffff"),
+			prefixLen: 64,
+		},
+		{
+			desc:      "EUI-48 /48",
+			ip:        eui48,
+			prefixLen: 48,
+			ok:        true,
+		},
+		{
+			desc:      "EUI-48 /64",
+			ip:        eui48,
+			prefixLen: 64,
+			ok:        true,
+		},
+		{
+			desc:      "EUI-64 /0",
+			ip:        net.ParseIP("2001:db8::212:7f00:eb:6b40"),
+			prefixLen: 0,
+			ok:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ok, err := VerifySplit(tt.ip, tt.prefixLen)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.ok, ok; want != got {
+				t.Fatalf("unexpected result:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestParseIPString verifies that ParseIPString parses IPv6 addresses from
+// strings and returns their prefixes and MAC addresses in canonical form.
+func TestParseIPString(t *testing.T) {
+	tests := []struct {
+		desc        string
+		s           string
+		prefix, mac string
+		ok          bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "malformed",
+			s:    "foo",
+		},
+		{
+			desc: "IPv4",
+			s:    "192.0.2.1",
+		},
+		{
+			desc:   "OK",
+			s:      "FE80:0000::0212:7FFF:FEEB:6B40",
+			prefix: "fe80::",
+			mac:    "00:12:7f:eb:6b:40",
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			prefix, mac, err := ParseIPString(tt.s)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse IP: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if want, got := tt.prefix, prefix; want != got {
+				t.Fatalf("unexpected IPv6 prefix:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := tt.mac, mac; want != got {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestParseMACString verifies that ParseMACString parses IPv6 prefixes and MAC
+// addresses from strings and returns IPv6 addresses in canonical form.
+func TestParseMACString(t *testing.T) {
+	tests := []struct {
+		desc        string
+		prefix, mac string
+		ip          string
+		ok          bool
+	}{
+		{
+			desc:   "malformed prefix",
+			prefix: "foo",
+			mac:    "00:12:7f:eb:6b:40",
+		},
+		{
+			desc:   "malformed MAC",
+			prefix: "fe80::",
+			mac:    "foo",
+		},
+		{
+			desc:   "invalid prefix",
+			prefix: "fe80::1",
+			mac:    "00:12:7f:eb:6b:40",
+		},
+		{
+			desc:   "OK EUI-48",
+			prefix: "fe80::",
+			mac:    "00-12-7F-EB-6B-40",
+			ip:     "fe80::212:7fff:feeb:6b40",
+			ok:     true,
+		},
+		{
+			desc:   "OK EUI-64",
+			prefix: "2001:db8::",
+			mac:    "0012.7fff.feeb.6b40",
+			ip:     "2001:db8::212:7fff:feeb:6b40",
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ip, err := ParseMACString(tt.prefix, tt.mac)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse MAC: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if want, got := tt.ip, ip; want != got {
+				t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestParseMACStringMACError verifies that ParseMACString reports the cause of
+// a malformed MAC address.
+func TestParseMACStringMACError(t *testing.T) {
+	_, err := ParseMACString("fe80::", "foo")
+
+	var aerr *net.AddrError
+	if !errors.As(err, &aerr) {
+		t.Fatalf("expected *net.AddrError, but got: %v", err)
+	}
+}
+
 // TestParseMAC verifies that ParseMAC generates appropriate output IPv6
 // addresses for input IPv6 prefixes and EUI-48 or EUI-64 MAC addresses.
 func TestParseMAC(t *testing.T) {
 	tests := []struct {
 		desc   string
 		prefix net.IP
-		mac    net.HardwareAddr
-		ip     net.IP
+		mac    net.HardwareAddr
+		ip     net.IP
+		err    error
+	}{
+		{
+			desc: "nil IPv6 prefix",
+			err:  errInvalidIP,
+		},
+		{
+			desc:   "short IPv6 prefix",
+			prefix: bytes.Repeat([]byte{0}, 15),
+			err:    errInvalidIP,
+		},
+		{
+			desc:   "long IPv6 prefix",
+			prefix: bytes.Repeat([]byte{0}, 17),
+			err:    errInvalidIP,
+		},
+		{
+			desc:   "IPv4 prefix",
+			prefix: net.IPv4(192, 168, 1, 1),
+			err:    errInvalidIP,
+		},
+		{
+			desc:   "IPv6 /128 prefix",
+			prefix: net.ParseIP("fe80::1"),
+			err:    errInvalidPrefix,
+		},
+		{
+			desc:   "nil MAC address",
+			prefix: net.ParseIP("fe80::"),
+			err:    errInvalidMAC,
+		},
+		{
+			desc:   "length 5 MAC address",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde},
+			err:    errInvalidMAC,
+		},
+		{
+			desc:   "length 9 MAC address",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad, 0xbe, 0xef, 0xde},
+			err:    errInvalidMAC,
+		},
+		{
+			desc:   "EUI-48 MAC address 02:00:00:00:00:01",
+			prefix: net.ParseIP("2002:db8::"),
+			mac:    net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+			ip:     net.ParseIP("2002:db8::ff:fe00:1"),
+		},
+		{
+			desc:   "EUI-48 MAC address 00:12:7f:eb:6b:40",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			ip:     net.ParseIP("fe80::212:7fff:feeb:6b40"),
+		},
+		{
+			desc:   "EUI-48 MAC address 22:ac:9e:18:be:80",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0x22, 0xac, 0x9e, 0x18, 0xbe, 0x80},
+			ip:     net.ParseIP("fe80::20ac:9eff:fe18:be80"),
+		},
+		{
+			desc:   "EUI-48 multicast MAC address 01:00:5e:00:00:01",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0x01, 0x00, 0x5e, 0x00, 0x00, 0x01},
+			ip:     net.ParseIP("fe80::300:5eff:fe00:1"),
+		},
+		{
+			desc:   "EUI-48 locally-administered multicast MAC address 03:00:00:00:00:01",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0x03, 0x00, 0x00, 0x00, 0x00, 0x01},
+			ip:     net.ParseIP("fe80::100:ff:fe00:1"),
+		},
+		{
+			desc:   "EUI-64 MAC address 00:00:00:ff:fe:00:00:01",
+			prefix: net.ParseIP("2002:db8::"),
+			mac:    net.HardwareAddr{0x00, 0x00, 0x00, 0xff, 0xfe, 0x00, 0x00, 0x01},
+			ip:     net.ParseIP("2002:db8::200:ff:fe00:1"),
+		},
+		{
+			desc:   "EUI-64 MAC address 00:12:7f:ff:fe:eb:6b:40",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+			ip:     net.ParseIP("fe80::212:7fff:feeb:6b40"),
+		},
+		{
+			desc:   "EUI-64 MAC address 22:ac:9e:ff:fe:18:be:80",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0x22, 0xac, 0x9e, 0xff, 0xfe, 0x18, 0xbe, 0x80},
+			ip:     net.ParseIP("fe80::20ac:9eff:fe18:be80"),
+		},
+	}
+
+	for i, tt := range tests {
+		// Copy input values to ensure they are not modified later
+		origPrefix := make(net.IP, len(tt.prefix))
+		copy(origPrefix, tt.prefix)
+		origMAC := make(net.HardwareAddr, len(tt.mac))
+		copy(origMAC, tt.mac)
+
+		ip, err := ParseMAC(tt.prefix, tt.mac)
+		if err != nil {
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("[%02d] test %q, unexpected error:\n- want: %v\n-  got: %v",
+					i, tt.desc, want, got)
+			}
+
+			continue
+		}
+
+		// Verify input values were not modified
+		if want, got := origPrefix, tt.prefix; !want.Equal(got) {
+			t.Fatalf("[%02d] test %q, prefix was modified:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := origMAC, tt.mac; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, MAC was modified:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.ip, ip; !want.Equal(got) {
+			t.Fatalf("[%02d] test %q, unexpected IPv6 address:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+// TestParseMACRoundTrip verifies that ParseIP recovers the MAC address passed
+// to ParseMAC for every possible value of the first octet, so that the U/L bit
+// inversion never disturbs the I/G bit or any other bit.
+func TestParseMACRoundTrip(t *testing.T) {
+	prefix := net.ParseIP("fe80::")
+
+	for i := 0; i < 256; i++ {
+		for _, mac := range []net.HardwareAddr{
+			{byte(i), 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			{byte(i), 0x12, 0x7f, 0x00, 0x01, 0xeb, 0x6b, 0x40},
+		} {
+			ip, err := ParseMAC(prefix, mac)
+			if err != nil {
+				t.Fatalf("failed to parse MAC %v: %v", mac, err)
+			}
+
+			if want, got := byte(i)^0x02, ip[8]; want != got {
+				t.Fatalf("unexpected first interface identifier octet for %v:\n- want: %#02x\n-  got: %#02x",
+					mac, want, got)
+			}
+
+			gotPrefix, gotMAC, err := ParseIP(ip)
+			if err != nil {
+				t.Fatalf("failed to parse IP %v: %v", ip, err)
+			}
+
+			if want, got := prefix, gotPrefix; !want.Equal(got) {
+				t.Fatalf("unexpected IPv6 prefix for %v:\n- want: %v\n-  got: %v",
+					mac, want, got)
+			}
+			if want, got := mac, gotMAC; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		}
+	}
+}
+
+// TestParseMACPrefix verifies that ParseMACPrefix produces the same IPv6
+// addresses as ParseMAC for netip.Prefix inputs and validates the prefix.
+func TestParseMACPrefix(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+
+	tests := []struct {
+		desc   string
+		prefix netip.Prefix
+		mac    net.HardwareAddr
+		ip     netip.Addr
+		err    error
+	}{
+		{
+			desc: "zero prefix",
+			mac:  mac,
+			err:  errInvalidIP,
+		},
+		{
+			desc:   "IPv4 prefix",
+			prefix: netip.MustParsePrefix("192.168.1.0/24"),
+			mac:    mac,
+			err:    errInvalidIP,
+		},
+		{
+			desc:   "IPv4-mapped prefix",
+			prefix: netip.MustParsePrefix("::ffff:192.168.1.0/120"),
+			mac:    mac,
+			err:    errInvalidIP,
+		},
+		{
+			desc:   "/96 prefix",
+			prefix: netip.MustParsePrefix("2001:db8::/96"),
+			mac:    mac,
+			err:    errInvalidPrefix,
+		},
+		{
+			desc:   "host bits in first 64",
+			prefix: netip.MustParsePrefix("2001:db8:0:1::/48"),
+			mac:    mac,
+			err:    errHostBits,
+		},
+		{
+			desc:   "host bits in last 64",
+			prefix: netip.MustParsePrefix("2001:db8::1/64"),
+			mac:    mac,
+			err:    errHostBits,
+		},
+		{
+			desc:   "bad MAC",
+			prefix: netip.MustParsePrefix("2001:db8::/64"),
+			mac:    net.HardwareAddr{0xde, 0xad},
+			err:    errInvalidMAC,
+		},
+		{
+			desc:   "OK /64",
+			prefix: netip.MustParsePrefix("fe80::/64"),
+			mac:    mac,
+			ip:     netip.MustParseAddr("fe80::212:7fff:feeb:6b40"),
+		},
+		{
+			desc:   "OK /48",
+			prefix: netip.MustParsePrefix("2001:db8:1::/48"),
+			mac:    net.HardwareAddr{0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+			ip:     netip.MustParseAddr("2001:db8:1:0:212:7fff:feeb:6b40"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ip, err := ParseMACPrefix(tt.prefix, tt.mac)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.ip, ip; want != got {
+				t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestSplit verifies that Split splits IPv6 addresses into prefixes and MAC
+// addresses at arbitrary prefix lengths.
+func TestSplit(t *testing.T) {
+	addr := netip.MustParseAddr("2001:db8:1:2300:212:7fff:feeb:6b40")
+	mac := net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+
+	tests := []struct {
+		desc      string
+		addr      netip.Addr
+		prefixLen int
+		prefix    netip.Prefix
+		mac       net.HardwareAddr
+		err       error
+	}{
+		{
+			desc: "invalid address",
+			err:  errInvalidIP,
+		},
+		{
+			desc: "IPv4 address",
+			addr: netip.MustParseAddr("192.0.2.1"),
+			err:  errInvalidIP,
+		},
+		{
+			desc: "IPv4-mapped IPv6 address",
+			addr: netip.MustParseAddr("::ffff:192.0.2.1"),
+			err:  errInvalidIP,
+		},
+		{
+			desc:      "negative prefix length",
+			addr:      addr,
+			prefixLen: -1,
+			err:       errInvalidPrefix,
+		},
+		{
+			desc:      "/65 prefix",
+			addr:      addr,
+			prefixLen: 65,
+			err:       errInvalidPrefix,
+		},
+		{
+			desc:      "random interface identifier",
+			addr:      netip.MustParseAddr("2001:db8:1:2300:3c4d:8e1a:9b2f:7c10"),
+			prefixLen: 48,
+			err:       errNotDerived,
+		},
+		{
+			desc:      "opaque interface identifier",
+			addr:      netip.MustParseAddr("2001:db8:1:2300::1"),
+			prefixLen: 56,
+			err:       errNotDerived,
+		},
+		{
+			desc:      "/48 prefix EUI-64",
+			addr:      netip.MustParseAddr("2001:db8:1:2300:212:7f00:eb:6b40"),
+			prefixLen: 48,
+			prefix:    netip.MustParsePrefix("2001:db8:1::/48"),
+			mac:       net.HardwareAddr{0x00, 0x12, 0x7f, 0x00, 0x00, 0xeb, 0x6b, 0x40},
+		},
+		{
+			desc:      "/48 prefix",
+			addr:      addr,
+			prefixLen: 48,
+			prefix:    netip.MustParsePrefix("2001:db8:1::/48"),
+			mac:       mac,
+		},
+		{
+			desc:      "/56 prefix",
+			addr:      addr,
+			prefixLen: 56,
+			prefix:    netip.MustParsePrefix("2001:db8:1:2300::/56"),
+			mac:       mac,
+		},
+		{
+			desc:      "/64 prefix with zone",
+			addr:      netip.MustParseAddr("fe80::212:7fff:feeb:6b40%eth0"),
+			prefixLen: 64,
+			prefix:    netip.MustParsePrefix("fe80::/64"),
+			mac:       mac,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			prefix, mac, err := Split(tt.addr, tt.prefixLen)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.prefix, prefix; want != got {
+				t.Fatalf("unexpected IPv6 prefix:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := tt.mac, mac; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestParseMACMulti verifies that ParseMACMulti produces one IPv6 address per
+// prefix and identifies the index of the first invalid prefix.
+func TestParseMACMulti(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+
+	tests := []struct {
+		desc     string
+		prefixes []net.IP
+		mac      net.HardwareAddr
+		ips      []net.IP
+		idx      int
+		err      error
+	}{
+		{
+			desc:     "bad MAC",
+			prefixes: []net.IP{net.ParseIP("fe80::")},
+			mac:      net.HardwareAddr{0xde, 0xad},
+			err:      errInvalidMAC,
+		},
+		{
+			desc: "bad second prefix",
+			prefixes: []net.IP{
+				net.ParseIP("fe80::"),
+				net.ParseIP("2001:db8::1"),
+				net.IPv4(192, 168, 1, 1),
+			},
+			mac: mac,
+			idx: 1,
+			err: errInvalidPrefix,
+		},
+		{
+			desc: "OK",
+			prefixes: []net.IP{
+				net.ParseIP("fe80::"),
+				net.ParseIP("2001:db8:0:10::"),
+				net.ParseIP("2001:db8:0:20::"),
+			},
+			mac: mac,
+			ips: []net.IP{
+				net.ParseIP("fe80::212:7fff:feeb:6b40"),
+				net.ParseIP("2001:db8:0:10:212:7fff:feeb:6b40"),
+				net.ParseIP("2001:db8:0:20:212:7fff:feeb:6b40"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			// Copy input values to ensure they are not modified later
+			origPrefixes := make([]net.IP, 0, len(tt.prefixes))
+			for _, p := range tt.prefixes {
+				origPrefixes = append(origPrefixes, append(net.IP(nil), p...))
+			}
+			origMAC := append(net.HardwareAddr(nil), tt.mac...)
+
+			ips, err := ParseMACMulti(tt.prefixes, tt.mac)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+						tt.err, err)
+				}
+
+				if tt.err != errInvalidMAC {
+					want := fmt.Sprintf("index %d", tt.idx)
+					if !strings.Contains(err.Error(), want) {
+						t.Fatalf("error %q does not contain %q", err, want)
+					}
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse MAC: %v", err)
+			}
+
+			for i := range origPrefixes {
+				if want, got := origPrefixes[i], tt.prefixes[i]; !want.Equal(got) {
+					t.Fatalf("prefix was modified:\n- want: %v\n-  got: %v",
+						want, got)
+				}
+			}
+			if want, got := origMAC, tt.mac; !bytes.Equal(want, got) {
+				t.Fatalf("MAC was modified:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := len(tt.ips), len(ips); want != got {
+				t.Fatalf("unexpected number of IPs:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			for i := range ips {
+				if want, got := tt.ips[i], ips[i]; !want.Equal(got) {
+					t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+						want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestInterfaceAddrs verifies that InterfaceAddrs produces the link-local
+// address followed by one address per Global Unicast or Unique Local prefix.
+func TestInterfaceAddrs(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+
+	tests := []struct {
+		desc     string
+		mac      net.HardwareAddr
+		prefixes []net.IP
+		ips      []net.IP
+		idx      int
+		err      error
+	}{
+		{
+			desc: "bad MAC",
+			mac:  net.HardwareAddr{0xde, 0xad},
+			err:  errInvalidMAC,
+		},
+		{
+			desc: "all zeroes MAC",
+			mac:  make(net.HardwareAddr, 6),
+			err:  errZeroMAC,
+		},
+		{
+			desc: "bad prefix",
+			mac:  mac,
+			prefixes: []net.IP{
+				net.ParseIP("2001:db8::"),
+				net.ParseIP("2001:db8::1"),
+			},
+			idx: 1,
+			err: errInvalidPrefix,
+		},
+		{
+			desc: "link-local prefix",
+			mac:  mac,
+			prefixes: []net.IP{
+				net.ParseIP("fe80::"),
+			},
+			err: errPrefixScope,
+		},
+		{
+			desc: "multicast prefix",
+			mac:  mac,
+			prefixes: []net.IP{
+				net.ParseIP("fd00::"),
+				net.ParseIP("ff02::"),
+			},
+			idx: 1,
+			err: errPrefixScope,
+		},
+		{
+			desc: "no prefixes",
+			mac:  mac,
+			ips: []net.IP{
+				net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			},
+		},
+		{
+			desc: "OK",
+			mac:  mac,
+			prefixes: []net.IP{
+				net.ParseIP("2001:db8:0:10::"),
+				net.ParseIP("fd00:0:0:20::"),
+			},
+			ips: []net.IP{
+				net.ParseIP("fe80::212:7fff:feeb:6b40"),
+				net.ParseIP("2001:db8:0:10:212:7fff:feeb:6b40"),
+				net.ParseIP("fd00:0:0:20:212:7fff:feeb:6b40"),
+			},
+		},
+		{
+			desc: "OK EUI-64",
+			mac:  net.HardwareAddr{0x00, 0x12, 0x7f, 0x00, 0x00, 0xeb, 0x6b, 0x40},
+			prefixes: []net.IP{
+				net.ParseIP("2001:db8::"),
+			},
+			ips: []net.IP{
+				net.ParseIP("fe80::212:7f00:eb:6b40"),
+				net.ParseIP("2001:db8::212:7f00:eb:6b40"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ips, err := InterfaceAddrs(tt.mac, tt.prefixes)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+						tt.err, err)
+				}
+
+				if len(tt.prefixes) > 0 {
+					want := fmt.Sprintf("index %d", tt.idx)
+					if !strings.Contains(err.Error(), want) {
+						t.Fatalf("error %q does not contain %q", err, want)
+					}
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to produce addresses: %v", err)
+			}
+
+			if want, got := len(tt.ips), len(ips); want != got {
+				t.Fatalf("unexpected number of addresses:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			for i := range ips {
+				if want, got := tt.ips[i], ips[i]; !want.Equal(got) {
+					t.Fatalf("unexpected address %d:\n- want: %v\n-  got: %v",
+						i, want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestParseShortAddr verifies that ParseShortAddr produces IPv6 addresses from
+// IEEE 802.15.4 PAN IDs and short addresses as described by RFC 4944 and RFC
+// 6282.
+func TestParseShortAddr(t *testing.T) {
+	tests := []struct {
+		desc   string
+		prefix net.IP
+		pan    uint16
+		short  uint16
+		ip     net.IP
+		err    error
+	}{
+		{
+			desc:   "IPv4 prefix",
+			prefix: net.IPv4(192, 168, 1, 1),
+			err:    errInvalidIP,
+		},
+		{
+			desc:   "/96 prefix",
+			prefix: net.ParseIP("fe80::1"),
+			err:    errInvalidPrefix,
+		},
+		{
+			// RFC 6282, section 3.2.2: a link-local address derived from a
+			// 16-bit short address uses the interface identifier
+			// 0000:00ff:fe00:XXXX.
+			desc:   "RFC 6282 link-local short address",
+			prefix: net.ParseIP("fe80::"),
+			short:  0x1234,
+			ip:     net.ParseIP("fe80::ff:fe00:1234"),
+		},
+		{
+			// RFC 4944, section 6: the interface identifier is formed from the
+			// 48-bit pseudo-address PAN ID:0000:short, with 0xff and 0xfe
+			// inserted as for an EUI-48 and the U/L bit set to zero.
+			desc:   "RFC 4944 PAN ID and short address",
+			prefix: net.ParseIP("fe80::"),
+			pan:    0x1234,
+			short:  0x0001,
+			ip:     net.ParseIP("fe80::1034:ff:fe00:1"),
+		},
+		{
+			// RFC 4944, section 7: link-local addresses are formed by
+			// appending the interface identifier to fe80::/64.
+			desc:   "RFC 4944 link-local address",
+			prefix: net.ParseIP("fe80::"),
+			pan:    0xffff,
+			short:  0x0000,
+			ip:     net.ParseIP("fe80::fdff:ff:fe00:0"),
+		},
+		{
+			desc:   "PAN ID U/L bit cleared",
+			prefix: net.ParseIP("fe80::"),
+			pan:    0xabcd,
+			short:  0x0001,
+			ip:     net.ParseIP("fe80::a9cd:ff:fe00:1"),
+		},
+		{
+			desc:   "global prefix",
+			prefix: net.ParseIP("2001:db8::"),
+			pan:    0x0100,
+			short:  0xfffe,
+			ip:     net.ParseIP("2001:db8::100:ff:fe00:fffe"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ip, err := ParseShortAddr(tt.prefix, tt.pan, tt.short)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.ip, ip; !want.Equal(got) {
+				t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestNormalizeMAC verifies that NormalizeMAC converts encapsulated EUI-48
+// addresses to EUI-48 form and rejects unusable MAC addresses.
+func TestNormalizeMAC(t *testing.T) {
+	tests := []struct {
+		desc string
+		mac  net.HardwareAddr
+		out  net.HardwareAddr
+		err  error
+	}{
+		{
+			desc: "nil MAC address",
+			err:  errInvalidMAC,
+		},
+		{
+			desc: "length 5 MAC address",
+			mac:  net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde},
+			err:  errInvalidMAC,
+		},
+		{
+			desc: "InfiniBand MAC address",
+			mac:  mustParseMAC("00:00:00:00:fe:80:00:00:00:00:00:00:02:00:5e:10:00:00:00:01"),
+			err:  errInfiniBandMAC,
+		},
+		{
+			desc: "EUI-48 all zeroes",
+			mac:  net.HardwareAddr{0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			err:  errZeroMAC,
+		},
+		{
+			desc: "EUI-48 broadcast",
+			mac:  net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			err:  errBroadcastMAC,
+		},
+		{
+			desc: "EUI-64 all zeroes",
+			mac:  net.HardwareAddr{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			err:  errZeroMAC,
+		},
+		{
+			desc: "EUI-64 all ones",
+			mac:  net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			err:  errBroadcastMAC,
+		},
+		{
+			desc: "EUI-48 OK",
+			mac:  mustParseMAC("00:12:7f:eb:6b:40"),
+			out:  net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+		},
+		{
+			desc: "EUI-64 OK",
+			mac:  mustParseMAC("00:12:7f:00:00:eb:6b:40"),
+			out:  net.HardwareAddr{0x00, 0x12, 0x7f, 0x00, 0x00, 0xeb, 0x6b, 0x40},
+		},
+		{
+			desc: "EUI-64 encapsulating EUI-48",
+			mac:  mustParseMAC("00:12:7f:ff:fe:eb:6b:40"),
+			out:  net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out, err := NormalizeMAC(tt.mac)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.out, out; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			// The output must not alias the input.
+			if len(out) > 0 && &out[0] == &tt.mac[0] {
+				t.Fatal("output aliases input MAC address")
+			}
+		})
+	}
+}
+
+func mustParseMAC(s string) net.HardwareAddr {
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse MAC address: %v", err))
+	}
+
+	return mac
+}
+
+// TestParseMACStrict verifies that ParseMACStrict rejects sentinel MAC
+// addresses which ParseMAC permits.
+func TestParseMACStrict(t *testing.T) {
+	tests := []struct {
+		desc string
+		mac  net.HardwareAddr
+		err  error
+	}{
+		{
+			desc: "length 5 MAC address",
+			mac:  net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde},
+			err:  errInvalidMAC,
+		},
+		{
+			desc: "EUI-48 all zeroes",
+			mac:  net.HardwareAddr{0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			err:  errZeroMAC,
+		},
+		{
+			desc: "EUI-48 broadcast",
+			mac:  net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			err:  errBroadcastMAC,
+		},
+		{
+			desc: "EUI-64 all zeroes",
+			mac:  net.HardwareAddr{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			err:  errZeroMAC,
+		},
+		{
+			desc: "EUI-64 all ones",
+			mac:  net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			err:  errBroadcastMAC,
+		},
+		{
+			desc: "EUI-48 OK",
+			mac:  net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+		},
+	}
+
+	prefix := net.ParseIP("fe80::")
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ip, err := ParseMACStrict(prefix, tt.mac)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			// Valid input must produce the same result as ParseMAC.
+			want, err := ParseMAC(prefix, tt.mac)
+			if err != nil {
+				t.Fatalf("failed to parse MAC: %v", err)
+			}
+
+			if !want.Equal(ip) {
+				t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+					want, ip)
+			}
+		})
+	}
+
+	// The permissive ParseMAC still accepts sentinel MAC addresses.
+	if _, err := ParseMAC(prefix, make(net.HardwareAddr, 6)); err != nil {
+		t.Fatalf("failed to parse all zeroes MAC: %v", err)
+	}
+}
+
+// TestMACFromDUID verifies that MACFromDUID recovers MAC addresses from DUID-LL
+// and DUID-LLT DUIDs and rejects other DUID types and hardware types.
+func TestMACFromDUID(t *testing.T) {
+	tests := []struct {
+		desc string
+		duid []byte
+		mac  net.HardwareAddr
+		err  error
+	}{
+		{
+			desc: "empty",
+			err:  errInvalidDUID,
+		},
+		{
+			desc: "short LLT",
+			duid: []byte{0x00, 0x01, 0x00, 0x01, 0x00, 0x00},
+			err:  errInvalidDUID,
+		},
+		{
+			desc: "EN",
+			duid: []byte{0x00, 0x02, 0x00, 0x00, 0x00, 0x09, 0xde, 0xad},
+			err:  errUnsupported,
+		},
+		{
+			desc: "UUID",
+			duid: append([]byte{0x00, 0x04}, make([]byte, 16)...),
+			err:  errUnsupported,
+		},
+		{
+			desc: "LL unsupported hardware type",
+			duid: []byte{0x00, 0x03, 0x00, 0x06, 0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			err:  errUnsupported,
+		},
+		{
+			desc: "LL Ethernet wrong length",
+			duid: []byte{0x00, 0x03, 0x00, 0x01, 0x00, 0x12, 0x7f, 0xeb, 0x6b},
+			err:  errInvalidDUID,
+		},
+		{
+			desc: "LL Ethernet",
+			duid: []byte{0x00, 0x03, 0x00, 0x01, 0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			mac:  net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+		},
+		{
+			desc: "LL EUI-64",
+			duid: []byte{
+				0x00, 0x03, 0x00, 0x1b,
+				0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40,
+			},
+			mac: net.HardwareAddr{0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+		},
+		{
+			desc: "LLT Ethernet",
+			duid: []byte{
+				0x00, 0x01, 0x00, 0x01,
+				0x1c, 0x39, 0xcf, 0x88,
+				0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40,
+			},
+			mac: net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mac, err := MACFromDUID(tt.duid)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					tt.err, err)
+			}
+
+			if want, got := tt.mac, mac; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestMACFromClientLLAddr verifies that MACFromClientLLAddr extracts MAC
+// addresses from DHCPv6 Client Link-Layer Address options.
+func TestMACFromClientLLAddr(t *testing.T) {
+	tests := []struct {
+		desc string
+		opt  []byte
+		mac  net.HardwareAddr
+		err  error
+	}{
+		{
+			desc: "empty",
+			err:  errInvalidLLAddr,
+		},
+		{
+			desc: "short",
+			opt:  []byte{0x00},
+			err:  errInvalidLLAddr,
+		},
+		{
+			desc: "unsupported hardware type",
+			opt:  []byte{0x00, 0x06, 0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			err:  errUnsupported,
+		},
+		{
+			desc: "Ethernet no address",
+			opt:  []byte{0x00, 0x01},
+			err:  errInvalidLLAddr,
+		},
+		{
+			desc: "Ethernet wrong length",
+			opt:  []byte{0x00, 0x01, 0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+			err:  errInvalidLLAddr,
+		},
+		{
+			desc: "Ethernet",
+			opt:  []byte{0x00, 0x01, 0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			mac:  net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+		},
+		{
+			desc: "EUI-64",
+			opt:  []byte{0x00, 0x1b, 0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+			mac:  net.HardwareAddr{0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mac, err := MACFromClientLLAddr(tt.opt)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					tt.err, err)
+			}
+
+			if want, got := tt.mac, mac; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected MAC address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			// The MAC address must not alias the option.
+			if len(mac) > 0 && &mac[0] == &tt.opt[2] {
+				t.Fatal("MAC address aliases input option")
+			}
+		})
+	}
+}
+
+// TestAppendIP verifies that AppendIP appends the same address produced by
+// ParseMAC to a buffer.
+func TestAppendIP(t *testing.T) {
+	tests := []struct {
+		desc   string
+		prefix net.IP
+		mac    net.HardwareAddr
+		err    error
+	}{
+		{
+			desc:   "IPv6 /128 prefix",
+			prefix: net.ParseIP("fe80::1"),
+			mac:    net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			err:    errInvalidPrefix,
+		},
+		{
+			desc:   "length 5 MAC address",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde},
+			err:    errInvalidMAC,
+		},
+		{
+			desc:   "EUI-48 MAC address",
+			prefix: net.ParseIP("fe80::"),
+			mac:    net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+		},
+		{
+			desc:   "EUI-64 MAC address",
+			prefix: net.ParseIP("2002:db8::"),
+			mac:    net.HardwareAddr{0x00, 0x00, 0x00, 0xff, 0xfe, 0x00, 0x00, 0x01},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			// Existing buffer contents must be preserved.
+			hdr := []byte{0xde, 0xad}
+
+			b, err := AppendIP(hdr, tt.prefix, tt.mac)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				if want, got := hdr, b; !bytes.Equal(want, got) {
+					t.Fatalf("buffer was modified:\n- want: %v\n-  got: %v",
+						want, got)
+				}
+
+				return
+			}
+
+			ip, err := ParseMAC(tt.prefix, tt.mac)
+			if err != nil {
+				t.Fatalf("failed to parse MAC: %v", err)
+			}
+
+			if want, got := append([]byte{0xde, 0xad}, ip...), b; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected buffer:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestAppendIPAllocations verifies that AppendIP does not allocate when the
+// buffer has sufficient capacity.
+func TestAppendIPAllocations(t *testing.T) {
+	var (
+		prefix = net.ParseIP("fe80::")
+		mac    = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+		b      = make([]byte, 0, 16)
+	)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = AppendIP(b[:0], prefix, mac)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("unexpected allocations: %v", allocs)
+	}
+}
+
+// BenchmarkAppendIP measures the cost of encoding an address into an existing
+// buffer.
+func BenchmarkAppendIP(b *testing.B) {
+	var (
+		prefix = net.ParseIP("fe80::")
+		mac    = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+		buf    = make([]byte, 0, 16)
+	)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := AppendIP(buf[:0], prefix, mac); err != nil {
+			b.Fatalf("failed to append IP: %v", err)
+		}
+	}
+}
+
+// TestCandidateMACs verifies that CandidateMACs returns the RFC-conformant and
+// non-conformant interpretations of interface identifiers.
+func TestCandidateMACs(t *testing.T) {
+	tests := []struct {
+		desc string
+		iid  [8]byte
+		macs []net.HardwareAddr
+	}{
+		{
+			desc: "EUI-48",
+			iid:  [8]byte{0x02, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+			macs: []net.HardwareAddr{
+				{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+				{0x02, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			},
+		},
+		{
+			desc: "EUI-64",
+			iid:  [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+			macs: []net.HardwareAddr{
+				{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+				{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			macs := CandidateMACs(tt.iid)
+			if want, got := len(tt.macs), len(macs); want != got {
+				t.Fatalf("unexpected number of MAC addresses:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			for i := range macs {
+				if want, got := tt.macs[i], macs[i]; !bytes.Equal(want, got) {
+					t.Fatalf("unexpected MAC address %d:\n- want: %v\n-  got: %v",
+						i, want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestIIDUint64 verifies that IIDUint64 and IPFromIIDUint64 convert between
+// IPv6 addresses and integer interface identifiers.
+func TestIIDUint64(t *testing.T) {
+	tests := []struct {
+		desc   string
+		ip     net.IP
+		prefix net.IP
+		iid    uint64
+		err    error
+	}{
+		{
+			desc: "IPv4 address",
+			ip:   net.IPv4(192, 168, 1, 1),
+			err:  errInvalidIP,
+		},
+		{
+			desc:   "EUI-48 MAC",
+			ip:     net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			prefix: net.ParseIP("fe80::"),
+			iid:    0x0212_7fff_feeb_6b40,
+		},
+		{
+			desc:   "low address",
+			ip:     net.ParseIP("2001:db8::1"),
+			prefix: net.ParseIP("2001:db8::"),
+			iid:    1,
+		},
+		{
+			desc:   "all ones",
+			ip:     net.ParseIP("2001:db8::ffff:ffff:ffff:ffff"),
+			prefix: net.ParseIP("2001:db8::"),
+			iid:    0xffff_ffff_ffff_ffff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			iid, err := IIDUint64(tt.ip)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.iid, iid; want != got {
+				t.Fatalf("unexpected IID:\n- want: %#x\n-  got: %#x",
+					want, got)
+			}
+
+			ip, err := IPFromIIDUint64(tt.prefix, iid)
+			if err != nil {
+				t.Fatalf("failed to build IP: %v", err)
+			}
+
+			if want, got := tt.ip, ip; !want.Equal(got) {
+				t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestIPFromIIDUint64Errors verifies that IPFromIIDUint64 validates its
+// prefix.
+func TestIPFromIIDUint64Errors(t *testing.T) {
+	tests := []struct {
+		desc   string
+		prefix net.IP
 		err    error
 	}{
 		{
@@ -100,15 +1910,32 @@ func TestParseMAC(t *testing.T) {
 			err:  errInvalidIP,
 		},
 		{
-			desc:   "short IPv6 prefix",
-			prefix: bytes.Repeat([]byte{0}, 15),
-			err:    errInvalidIP,
-		},
-		{
-			desc:   "long IPv6 prefix",
-			prefix: bytes.Repeat([]byte{0}, 17),
-			err:    errInvalidIP,
+			desc:   "IPv6 /128 prefix",
+			prefix: net.ParseIP("fe80::1"),
+			err:    errInvalidPrefix,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := IPFromIIDUint64(tt.prefix, 1); tt.err != err {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					tt.err, err)
+			}
+		})
+	}
+}
+
+// TestIPFromIIDUint64WellKnown verifies that IPFromIIDUint64 produces
+// manually-assigned addresses without modifying the interface identifier.
+func TestIPFromIIDUint64WellKnown(t *testing.T) {
+	tests := []struct {
+		desc   string
+		prefix net.IP
+		iid    uint64
+		ip     net.IP
+		err    error
+	}{
 		{
 			desc:   "IPv4 prefix",
 			prefix: net.IPv4(192, 168, 1, 1),
@@ -116,95 +1943,312 @@ func TestParseMAC(t *testing.T) {
 		},
 		{
 			desc:   "IPv6 /128 prefix",
-			prefix: net.ParseIP("fe80::1"),
+			prefix: net.ParseIP("2001:db8::1"),
+			iid:    1,
 			err:    errInvalidPrefix,
 		},
 		{
-			desc:   "nil MAC address",
-			prefix: net.ParseIP("fe80::"),
-			err:    errInvalidMAC,
+			desc:   "subnet-router anycast",
+			prefix: net.ParseIP("2001:db8:0:1::"),
+			ip:     net.ParseIP("2001:db8:0:1::"),
 		},
 		{
-			desc:   "length 5 MAC address",
+			desc:   "link-local router",
 			prefix: net.ParseIP("fe80::"),
-			mac:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde},
-			err:    errInvalidMAC,
+			iid:    1,
+			ip:     net.ParseIP("fe80::1"),
 		},
 		{
-			desc:   "length 9 MAC address",
-			prefix: net.ParseIP("fe80::"),
-			mac:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad, 0xbe, 0xef, 0xde},
-			err:    errInvalidMAC,
+			// No U/L bit flip is applied.
+			desc:   "U/L bit untouched",
+			prefix: net.ParseIP("2001:db8::"),
+			iid:    0x0200000000000001,
+			ip:     net.ParseIP("2001:db8::200:0:0:1"),
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ip, err := IPFromIIDUint64(tt.prefix, tt.iid)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.ip, ip; !want.Equal(got) {
+				t.Fatalf("unexpected IPv6 address:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestIsPrivacyAddress verifies that IsPrivacyAddress detects addresses with
+// random interface identifiers.
+func TestIsPrivacyAddress(t *testing.T) {
+	tests := []struct {
+		desc string
+		ip   net.IP
+		ok   bool
+	}{
 		{
-			desc:   "EUI-48 MAC address 02:00:00:00:00:01",
-			prefix: net.ParseIP("2002:db8::"),
-			mac:    net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
-			ip:     net.ParseIP("2002:db8::ff:fe00:1"),
+			desc: "nil IP address",
 		},
 		{
-			desc:   "EUI-48 MAC address 00:12:7f:eb:6b:40",
-			prefix: net.ParseIP("fe80::"),
-			mac:    net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
-			ip:     net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			desc: "IPv4 address",
+			ip:   net.IPv4(192, 168, 1, 1),
 		},
 		{
-			desc:   "EUI-48 MAC address 22:ac:9e:18:be:80",
-			prefix: net.ParseIP("fe80::"),
-			mac:    net.HardwareAddr{0x22, 0xac, 0x9e, 0x18, 0xbe, 0x80},
-			ip:     net.ParseIP("fe80::20ac:9eff:fe18:be80"),
+			desc: "EUI-48",
+			ip:   net.ParseIP("fe80::212:7fff:feeb:6b40"),
 		},
 		{
-			desc:   "EUI-64 MAC address 00:00:00:ff:fe:00:00:01",
-			prefix: net.ParseIP("2002:db8::"),
-			mac:    net.HardwareAddr{0x00, 0x00, 0x00, 0xff, 0xfe, 0x00, 0x00, 0x01},
-			ip:     net.ParseIP("2002:db8::200:ff:fe00:1"),
+			desc: "EUI-64 universal",
+			ip:   net.ParseIP("2001:db8::212:7f00:eb:6b40"),
 		},
 		{
-			desc:   "EUI-64 MAC address 00:12:7f:ff:fe:eb:6b:40",
-			prefix: net.ParseIP("fe80::"),
-			mac:    net.HardwareAddr{0x00, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
-			ip:     net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			desc: "random",
+			ip:   net.ParseIP("2001:db8::d5e3:7953:13eb:22e8"),
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if want, got := tt.ok, IsPrivacyAddress(tt.ip); want != got {
+				t.Fatalf("unexpected privacy address result:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestClassify verifies that Classify guesses the origin of an IPv6 address's
+// interface identifier.
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		desc string
+		ip   net.IP
+		kind Kind
+		err  error
+	}{
+		{
+			desc: "nil IP address",
+			err:  errInvalidIP,
 		},
 		{
-			desc:   "EUI-64 MAC address 22:ac:9e:ff:fe:18:be:80",
-			prefix: net.ParseIP("fe80::"),
-			mac:    net.HardwareAddr{0x22, 0xac, 0x9e, 0xff, 0xfe, 0x18, 0xbe, 0x80},
-			ip:     net.ParseIP("fe80::20ac:9eff:fe18:be80"),
+			desc: "IPv4 address",
+			ip:   net.IPv4(192, 168, 1, 1),
+			err:  errInvalidIP,
+		},
+		{
+			desc: "EUI-48 universal",
+			ip:   net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			kind: EUI48Derived,
+		},
+		{
+			desc: "EUI-48 local",
+			ip:   net.ParseIP("fe80::ac:9eff:fe18:be80"),
+			kind: EUI48Derived,
+		},
+		{
+			desc: "EUI-64 universal",
+			ip:   net.ParseIP("2001:db8::212:7f00:eb:6b40"),
+			kind: EUI64Derived,
+		},
+		{
+			desc: "random",
+			ip:   net.ParseIP("2001:db8::d5e3:7953:13eb:22e8"),
+			kind: RandomOrOpaque,
+		},
+		{
+			desc: "low address",
+			ip:   net.ParseIP("2001:db8::1"),
+			kind: RandomOrOpaque,
 		},
 	}
 
-	for i, tt := range tests {
-		// Copy input values to ensure they are not modified later
-		origPrefix := make(net.IP, len(tt.prefix))
-		copy(origPrefix, tt.prefix)
-		origMAC := make(net.HardwareAddr, len(tt.mac))
-		copy(origMAC, tt.mac)
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			kind, err := Classify(tt.ip)
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
 
-		ip, err := ParseMAC(tt.prefix, tt.mac)
-		if err != nil {
+			if want, got := tt.kind, kind; want != got {
+				t.Fatalf("unexpected Kind:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+// TestInspect verifies that Inspect describes the prefix, MAC address, and
+// origin of IPv6 addresses.
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		desc string
+		ip   net.IP
+		out  Inspection
+		err  error
+	}{
+		{
+			desc: "nil IP address",
+			err:  errInvalidIP,
+		},
+		{
+			desc: "IPv4 address",
+			ip:   net.IPv4(192, 168, 1, 1),
+			err:  errInvalidIP,
+		},
+		{
+			desc: "link-local EUI-48 universal",
+			ip:   net.ParseIP("fe80::212:7fff:feeb:6b40"),
+			out: Inspection{
+				Prefix:    net.ParseIP("fe80::"),
+				MAC:       net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+				Kind:      EUI48Derived,
+				Universal: true,
+				LinkLocal: true,
+			},
+		},
+		{
+			desc: "unique local EUI-48 local multicast",
+			ip:   net.ParseIP("fd00::100:9eff:fe18:be80"),
+			out: Inspection{
+				Prefix:      net.ParseIP("fd00::"),
+				MAC:         net.HardwareAddr{0x03, 0x00, 0x9e, 0x18, 0xbe, 0x80},
+				Kind:        EUI48Derived,
+				Group:       true,
+				UniqueLocal: true,
+			},
+		},
+		{
+			desc: "global EUI-64 universal",
+			ip:   net.ParseIP("2001:db8::212:7f00:eb:6b40"),
+			out: Inspection{
+				Prefix:        net.ParseIP("2001:db8::"),
+				MAC:           net.HardwareAddr{0x00, 0x12, 0x7f, 0x00, 0x00, 0xeb, 0x6b, 0x40},
+				Kind:          EUI64Derived,
+				Universal:     true,
+				GlobalUnicast: true,
+			},
+		},
+		{
+			desc: "global random",
+			ip:   net.ParseIP("2001:db8:1:2:d5e3:7953:13eb:22e8"),
+			out: Inspection{
+				Prefix:        net.ParseIP("2001:db8:1:2::"),
+				Kind:          RandomOrOpaque,
+				Group:         true,
+				GlobalUnicast: true,
+			},
+		},
+		{
+			desc: "multicast scope",
+			ip:   net.ParseIP("ff02::1"),
+			out: Inspection{
+				Prefix: net.ParseIP("ff02::"),
+				Kind:   RandomOrOpaque,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out, err := Inspect(tt.ip)
 			if want, got := tt.err, err; want != got {
-				t.Fatalf("[%02d] test %q, unexpected error:\n- want: %v\n-  got: %v",
-					i, tt.desc, want, got)
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
 			}
 
-			continue
-		}
+			if want, got := tt.out, out; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Inspection:\n- want: %+v\n-  got: %+v",
+					want, got)
+			}
+		})
+	}
+}
 
-		// Verify input values were not modified
-		if want, got := origPrefix, tt.prefix; !want.Equal(got) {
-			t.Fatalf("[%02d] test %q, prefix was modified:\n- want: %v\n-  got: %v",
-				i, tt.desc, want, got)
-		}
-		if want, got := origMAC, tt.mac; !bytes.Equal(want, got) {
-			t.Fatalf("[%02d] test %q, MAC was modified:\n- want: %v\n-  got: %v",
-				i, tt.desc, want, got)
-		}
+// TestRanges verifies that IsGlobalUnicast, IsUniqueLocal, and IsLinkLocal
+// classify IPv6 addresses by their address ranges.
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		desc                           string
+		ip                             netip.Addr
+		global, uniqueLocal, linkLocal bool
+	}{
+		{
+			desc: "zero",
+		},
+		{
+			desc: "IPv4",
+			ip:   netip.MustParseAddr("192.0.2.1"),
+		},
+		{
+			desc: "IPv4-mapped",
+			ip:   netip.MustParseAddr("::ffff:192.0.2.1"),
+		},
+		{
+			desc: "loopback",
+			ip:   netip.MustParseAddr("::1"),
+		},
+		{
+			desc:   "global unicast",
+			ip:     netip.MustParseAddr("2001:db8::212:7fff:feeb:6b40"),
+			global: true,
+		},
+		{
+			desc:   "global unicast last",
+			ip:     netip.MustParseAddr("3fff:ffff::1"),
+			global: true,
+		},
+		{
+			desc:        "unique local fc",
+			ip:          netip.MustParseAddr("fc00::1"),
+			uniqueLocal: true,
+		},
+		{
+			desc:        "unique local fd",
+			ip:          netip.MustParseAddr("fd00::212:7fff:feeb:6b40"),
+			uniqueLocal: true,
+		},
+		{
+			desc:      "link-local",
+			ip:        netip.MustParseAddr("fe80::212:7fff:feeb:6b40"),
+			linkLocal: true,
+		},
+		{
+			desc:      "link-local last",
+			ip:        netip.MustParseAddr("febf::1"),
+			linkLocal: true,
+		},
+		{
+			desc: "site-local",
+			ip:   netip.MustParseAddr("fec0::1"),
+		},
+		{
+			desc: "multicast",
+			ip:   netip.MustParseAddr("ff02::1"),
+		},
+	}
 
-		if want, got := tt.ip, ip; !want.Equal(got) {
-			t.Fatalf("[%02d] test %q, unexpected IPv6 address:\n- want: %v\n-  got: %v",
-				i, tt.desc, want, got)
-		}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if want, got := tt.global, IsGlobalUnicast(tt.ip); want != got {
+				t.Fatalf("unexpected IsGlobalUnicast:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := tt.uniqueLocal, IsUniqueLocal(tt.ip); want != got {
+				t.Fatalf("unexpected IsUniqueLocal:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if want, got := tt.linkLocal, IsLinkLocal(tt.ip); want != got {
+				t.Fatalf("unexpected IsLinkLocal:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
 	}
 }
 
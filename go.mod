@@ -4,6 +4,21 @@ go 1.20
 
 require (
 	github.com/google/go-cmp v0.5.9
+	github.com/prometheus/client_golang v1.15.1
 	golang.org/x/net v0.9.0
 	golang.org/x/sync v0.1.0
+	golang.org/x/sys v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.4 // indirect
+	github.com/prometheus/client_model v0.3.0 // indirect
+	github.com/prometheus/common v0.42.0 // indirect
+	github.com/prometheus/procfs v0.9.0 // indirect
+	google.golang.org/protobuf v1.30.0 // indirect
 )
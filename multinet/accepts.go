@@ -0,0 +1,72 @@
+package multinet
+
+import (
+	"errors"
+	"net"
+)
+
+// Accepts produces an iterator over the connections accepted by the Listener,
+// for use in accept loops such as "for c := range l.Accepts()" with Go 1.23
+// or later. Each net.Conn is owned by the caller, which is
+// responsible for closing it. The iterator stops once the Listener is closed,
+// once Accept returns an error other than an *AcceptError, such as
+// ErrAllListenersClosed, or once yield returns false.
+//
+// As with Run, an *AcceptError originates from a single net.Listener which the
+// Listener continues to serve, so the iterator retries Accept after a brief
+// delay rather than stopping.
+//
+// Stopping iteration early by returning false from yield does not close the
+// Listener, which may be iterated again or passed to Accept. Once iteration
+// stops, Err reports why.
+func (l *Listener) Accepts() func(yield func(net.Conn) bool) {
+	return func(yield func(net.Conn) bool) {
+		l.setAcceptsErr(nil)
+
+		var b backoff
+		for {
+			c, err := l.Accept()
+			if err == nil {
+				b.reset()
+				if !yield(c) {
+					return
+				}
+
+				continue
+			}
+
+			if isClosed(l.doneC) {
+				// Stopped cleanly by Close.
+				return
+			}
+
+			var aerr *AcceptError
+			if !errors.As(err, &aerr) {
+				l.setAcceptsErr(err)
+				return
+			}
+
+			if !b.wait(l.doneC) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error which stopped the most recent iteration over Accepts,
+// or nil if the iteration stopped because the Listener was closed or because
+// yield returned false.
+func (l *Listener) Err() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.acceptsErr
+}
+
+// setAcceptsErr sets the error returned by Err.
+func (l *Listener) setAcceptsErr(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.acceptsErr = err
+}
@@ -0,0 +1,117 @@
+package multinet_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestListenerAccepts(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(tcp1, tcp2)
+	)
+
+	const n = 4
+	for i := 0; i < n; i++ {
+		addr := tcp1.Addr()
+		if i%2 == 1 {
+			addr = tcp2.Addr()
+		}
+
+		c, err := net.Dial(addr.Network(), addr.String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+	}
+
+	// Close the Listener once every connection is yielded, which must stop
+	// the iterator cleanly.
+	var yielded int
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		l.Accepts()(func(c net.Conn) bool {
+			_ = c.Close()
+			if yielded++; yielded == n {
+				go l.Close()
+			}
+
+			return true
+		})
+	}()
+
+	select {
+	case <-doneC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for iterator to stop")
+	}
+
+	if diff := cmp.Diff(n, yielded); diff != "" {
+		t.Fatalf("unexpected number of connections (-want +got):\n%s", diff)
+	}
+	if err := l.Err(); err != nil {
+		t.Fatalf("unexpected iterator error: %v", err)
+	}
+}
+
+func TestListenerAcceptsBreak(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(tcp)
+	defer l.Close()
+
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", tcp.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+
+		// Breaking after the first connection leaves the Listener open, so a
+		// later iteration yields the next connection.
+		var yielded int
+		l.Accepts()(func(c net.Conn) bool {
+			_ = c.Close()
+			yielded++
+			return false
+		})
+
+		if diff := cmp.Diff(1, yielded); diff != "" {
+			t.Fatalf("unexpected number of connections (-want +got):\n%s", diff)
+		}
+		if err := l.Err(); err != nil {
+			t.Fatalf("unexpected iterator error: %v", err)
+		}
+	}
+}
+
+func TestListenerAcceptsErr(t *testing.T) {
+	// A transient error from a single net.Listener is skipped, but the
+	// iterator stops once every net.Listener has failed permanently.
+	sl := newScriptListener([]error{errors.New("transient"), nil})
+	l := multinet.Listen(sl)
+	defer l.Close()
+
+	var yielded int
+	l.Accepts()(func(c net.Conn) bool {
+		_ = c.Close()
+		yielded++
+
+		// Close the net.Listener out from under the Listener.
+		_ = sl.Close()
+		return true
+	})
+
+	if diff := cmp.Diff(1, yielded); diff != "" {
+		t.Fatalf("unexpected number of connections (-want +got):\n%s", diff)
+	}
+	if err := l.Err(); !errors.Is(err, multinet.ErrAllListenersClosed) {
+		t.Fatalf("unexpected iterator error: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package multinet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// WithIgnoreBindErrors configures ListenAll to skip any address which cannot
+// be bound, such as one which is already in use (EADDRINUSE) or which is not
+// yet assigned to an interface, rather than failing the entire set.
+func WithIgnoreBindErrors() Option {
+	return func(c *config) { c.skip = true }
+}
+
+// ListenAll creates a Listener which aggregates stream listeners bound to each
+// of addresses on network, such as "tcp". ctx is used only while binding the
+// listeners, and opts are applied to the returned Listener.
+//
+// By default, if any bind fails, the listeners already bound are closed and an
+// error is returned. If WithIgnoreBindErrors is set, each address which cannot
+// be bound is skipped instead: ListenAll then returns a Listener which
+// aggregates the listeners which were bound, along with a non-nil error which
+// joins the errors for every skipped address. Callers should use the Listener
+// whenever it is non-nil and treat the error as a warning. If no address can be
+// bound, a nil Listener is returned.
+func ListenAll(ctx context.Context, network string, addresses []string, opts ...Option) (*Listener, error) {
+	var c config
+	for _, o := range opts {
+		o(&c)
+	}
+
+	var (
+		lc      net.ListenConfig
+		ls      = make([]net.Listener, 0, len(addresses))
+		skipped []error
+	)
+
+	for _, addr := range addresses {
+		ln, err := lc.Listen(ctx, network, addr)
+		if err == nil {
+			ls = append(ls, ln)
+			continue
+		}
+
+		err = fmt.Errorf("multinet: failed to listen on %s %s: %w", network, addr, err)
+		if !c.skip {
+			for _, ln := range ls {
+				_ = ln.Close()
+			}
+
+			return nil, err
+		}
+
+		skipped = append(skipped, err)
+	}
+
+	if len(ls) == 0 {
+		if len(skipped) == 0 {
+			return nil, errors.New("multinet: no addresses to listen on")
+		}
+
+		return nil, errors.Join(skipped...)
+	}
+
+	return NewListener(ls, opts...), errors.Join(skipped...)
+}
@@ -0,0 +1,81 @@
+package multinet_test
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestListenAll(t *testing.T) {
+	l, err := multinet.ListenAll(context.Background(), "tcp",
+		[]string{"127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	addrs := l.Addrs()
+	if diff := cmp.Diff(2, len(addrs)); diff != "" {
+		t.Fatalf("unexpected number of addresses (-want +got):\n%s", diff)
+	}
+
+	for _, addr := range addrs {
+		acceptOne(t, l, addr)
+	}
+}
+
+func TestListenAllBindError(t *testing.T) {
+	// Occupy an address so that binding it again fails with EADDRINUSE.
+	tcp := localListener("tcp4")
+	defer tcp.Close()
+
+	var (
+		used  = tcp.Addr().String()
+		addrs = []string{"127.0.0.1:0", used}
+	)
+
+	t.Run("fail", func(t *testing.T) {
+		l, err := multinet.ListenAll(context.Background(), "tcp", addrs)
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			t.Fatalf("unexpected listen error: %v", err)
+		}
+		if l != nil {
+			t.Fatal("expected a nil Listener")
+		}
+	})
+
+	t.Run("ignore", func(t *testing.T) {
+		l, err := multinet.ListenAll(context.Background(), "tcp", addrs,
+			multinet.WithIgnoreBindErrors())
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			t.Fatalf("unexpected listen error: %v", err)
+		}
+		if l == nil {
+			t.Fatal("expected a non-nil Listener")
+		}
+		defer l.Close()
+
+		// Only the first address was bound, and it accepts connections.
+		bound := l.Addrs()
+		if diff := cmp.Diff(1, len(bound)); diff != "" {
+			t.Fatalf("unexpected number of addresses (-want +got):\n%s", diff)
+		}
+
+		acceptOne(t, l, bound[0])
+	})
+
+	t.Run("ignore all", func(t *testing.T) {
+		l, err := multinet.ListenAll(context.Background(), "tcp", []string{used},
+			multinet.WithIgnoreBindErrors())
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			t.Fatalf("unexpected listen error: %v", err)
+		}
+		if l != nil {
+			t.Fatal("expected a nil Listener")
+		}
+	})
+}
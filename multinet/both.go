@@ -0,0 +1,56 @@
+package multinet
+
+import (
+	"net"
+	"time"
+)
+
+// Both aggregates multiple net.Listeners and net.PacketConns, for servers which
+// speak a protocol over both stream and datagram transports, such as DNS over
+// TCP and UDP.
+//
+// Both has two distinct method sets: Accept and Addr are provided by the
+// embedded *Listener to serve streams, and ReadFrom, WriteTo, LocalAddr,
+// SetReadDeadline, and SetWriteDeadline are provided by the embedded
+// *PacketConn to serve datagrams. Both is neither a net.Listener nor a
+// net.PacketConn; use the embedded fields where those interfaces are required.
+// Close and SetDeadline apply to both.
+type Both struct {
+	*Listener
+	*PacketConn
+}
+
+// ListenBoth creates a Both which aggregates the net.Listeners in streams and
+// the net.PacketConns in packets, using the input Options to configure both.
+func ListenBoth(streams []net.Listener, packets []net.PacketConn, opts ...Option) *Both {
+	return &Both{
+		Listener:   NewListener(streams, opts...),
+		PacketConn: NewPacketConn(packets, opts...),
+	}
+}
+
+// Close closes all net.Listeners and net.PacketConns owned by this Both. If
+// more than one returns an error, only the first error is returned.
+func (b *Both) Close() error {
+	lerr := b.Listener.Close()
+	perr := b.PacketConn.Close()
+	if lerr != nil {
+		return lerr
+	}
+
+	return perr
+}
+
+// SetDeadline sets a deadline t on all net.Listeners and net.PacketConns owned
+// by this Both. As with Listener.SetDeadline, all net.Listeners must support
+// deadlines. If more than one returns an error, only the first error is
+// returned.
+func (b *Both) SetDeadline(t time.Time) error {
+	lerr := b.Listener.SetDeadline(t)
+	perr := b.PacketConn.SetDeadline(t)
+	if lerr != nil {
+		return lerr
+	}
+
+	return perr
+}
@@ -0,0 +1,61 @@
+package multinet_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestBoth(t *testing.T) {
+	var (
+		tcp = localListener("tcp")
+		udp = localPacketConn("udp")
+		b   = multinet.ListenBoth([]net.Listener{tcp}, []net.PacketConn{udp})
+	)
+
+	if diff := cmp.Diff(multinet.Addr{tcp.Addr()}, b.Addr()); diff != "" {
+		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(multinet.Addr{udp.LocalAddr()}, b.LocalAddr()); diff != "" {
+		t.Fatalf("unexpected LocalAddr (-want +got):\n%s", diff)
+	}
+
+	// Serve a stream connection.
+	acceptOne(t, b, tcp.Addr())
+
+	// Serve a datagram.
+	c, err := net.Dial(udp.LocalAddr().Network(), udp.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Write([]byte("hello")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	buf := make([]byte, 16)
+	n, _, err := b.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	if diff := cmp.Diff("hello", string(buf[:n])); diff != "" {
+		t.Fatalf("unexpected datagram (-want +got):\n%s", diff)
+	}
+
+	// Close applies to both sets of connections.
+	if err := b.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if _, err := tcp.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.Listener to be closed, but got: %v", err)
+	}
+	if _, _, err := udp.ReadFrom(buf); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.PacketConn to be closed, but got: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package multinet
+
+import (
+	"context"
+	"net"
+)
+
+// WithContextFunc configures a Listener to call fn on each net.Conn it accepts
+// and attach the returned context.Context to the connection, such as to start
+// a tracing span for each connection. The context can be retrieved from the
+// net.Conns returned by Listener.Accept using ConnContext.
+//
+// The context is attached after any wrappers added by WithConnWrapper, so fn
+// observes the wrapped net.Conn, and type assertions on the net.Conns returned
+// by Listener.Accept will not observe the wrapped type.
+func WithContextFunc(fn func(net.Conn) context.Context) Option {
+	return func(c *config) { c.ctx = fn }
+}
+
+// ConnContext returns the context.Context attached to c by a Listener
+// configured with WithContextFunc. If no context is attached, ConnContext
+// returns context.Background.
+func ConnContext(c net.Conn) context.Context {
+	if cc, ok := c.(interface{ Context() context.Context }); ok {
+		if ctx := cc.Context(); ctx != nil {
+			return ctx
+		}
+	}
+
+	return context.Background()
+}
+
+// A contextConn is a net.Conn with an attached context.Context.
+type contextConn struct {
+	net.Conn
+	ctx context.Context
+}
+
+// Context returns the context.Context attached to the net.Conn.
+func (c *contextConn) Context() context.Context { return c.ctx }
@@ -0,0 +1,91 @@
+package multinet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestListenerContextFunc(t *testing.T) {
+	type key struct{}
+
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+	)
+
+	l := multinet.NewListener(
+		[]net.Listener{tcp1, tcp2},
+		multinet.WithConnWrapper(tcp1, func(c net.Conn) net.Conn {
+			return &taggedConn{Conn: c, tags: []string{"a"}}
+		}),
+		multinet.WithContextFunc(func(c net.Conn) context.Context {
+			// Report the wrapper tags observed by the context function, if any.
+			var tags []string
+			if tc, ok := c.(*taggedConn); ok {
+				tags = tc.tags
+			}
+
+			return context.WithValue(context.Background(), key{}, tags)
+		}),
+	)
+	defer l.Close()
+
+	accept := func(addr net.Addr) net.Conn {
+		c, err := net.Dial(addr.Network(), addr.String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+
+		ac, err := l.Accept()
+		if err != nil {
+			t.Fatalf("failed to accept: %v", err)
+		}
+		_ = ac.Close()
+
+		return ac
+	}
+
+	tests := []struct {
+		name string
+		addr net.Addr
+		tags []string
+	}{
+		{
+			name: "wrapped",
+			addr: tcp1.Addr(),
+			tags: []string{"a"},
+		},
+		{
+			name: "unwrapped",
+			addr: tcp2.Addr(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, ok := multinet.ConnContext(accept(tt.addr)).Value(key{}).([]string)
+			if !ok {
+				t.Fatal("connection context was not attached")
+			}
+
+			if diff := cmp.Diff(tt.tags, tags); diff != "" {
+				t.Fatalf("unexpected wrapper tags (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConnContextBackground(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	if ctx := multinet.ConnContext(c1); ctx != context.Background() {
+		t.Fatalf("unexpected context for plain net.Conn: %v", ctx)
+	}
+}
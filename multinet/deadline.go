@@ -0,0 +1,146 @@
+package multinet
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithDeadlineShim wraps a net.Listener which does not support deadlines so
+// that it gains a "SetDeadline(t time.Time) error" method, allowing it to be
+// used with Listener.SetDeadline alongside other net.Listeners.
+//
+// The deadline is implemented by accepting from ln in a background goroutine
+// and racing the result against a timer. When the deadline expires, Accept
+// returns a timeout error but ln remains open, and any connection accepted in
+// the meantime is returned by the next call to Accept. Closing the returned
+// net.Listener closes ln.
+func WithDeadlineShim(ln net.Listener) net.Listener {
+	return &deadlineShim{
+		ln:        ln,
+		acceptC:   make(chan accept),
+		doneC:     make(chan struct{}),
+		deadlineC: make(chan struct{}),
+	}
+}
+
+var _ deadlineListener = &deadlineShim{}
+
+// A deadlineShim is a net.Listener with deadlines implemented by racing an
+// accept goroutine against a timer.
+type deadlineShim struct {
+	ln                    net.Listener
+	acceptOnce, closeOnce sync.Once
+	acceptC               chan accept
+	doneC                 chan struct{}
+
+	// deadlineC is closed and replaced whenever the deadline changes so that
+	// pending calls to Accept can observe the new deadline.
+	mu        sync.Mutex
+	deadline  time.Time
+	deadlineC chan struct{}
+}
+
+// Accept implements net.Listener.
+func (s *deadlineShim) Accept() (net.Conn, error) {
+	s.acceptOnce.Do(func() { go s.accept() })
+
+	for {
+		s.mu.Lock()
+		deadline, changedC := s.deadline, s.deadlineC
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		if !deadline.IsZero() {
+			d := time.Until(deadline)
+			if d <= 0 {
+				return nil, s.opError(os.ErrDeadlineExceeded)
+			}
+
+			timer = time.NewTimer(d)
+		}
+
+		if a, ok := s.wait(timer, changedC); ok {
+			return a.c, a.err
+		}
+
+		// Deadline changed, check it again.
+	}
+}
+
+// wait waits for a connection, close, timer expiration, or deadline change,
+// reporting false only for a deadline change.
+func (s *deadlineShim) wait(timer *time.Timer, changedC <-chan struct{}) (accept, bool) {
+	var timerC <-chan time.Time
+	if timer != nil {
+		defer timer.Stop()
+		timerC = timer.C
+	}
+
+	select {
+	case a := <-s.acceptC:
+		return a, true
+	case <-s.doneC:
+		return accept{err: s.opError(net.ErrClosed)}, true
+	case <-timerC:
+		return accept{err: s.opError(os.ErrDeadlineExceeded)}, true
+	case <-changedC:
+		return accept{}, false
+	}
+}
+
+// Addr implements net.Listener.
+func (s *deadlineShim) Addr() net.Addr { return s.ln.Addr() }
+
+// Close implements net.Listener.
+func (s *deadlineShim) Close() error {
+	err := s.opError(net.ErrClosed)
+	s.closeOnce.Do(func() {
+		close(s.doneC)
+		err = s.ln.Close()
+	})
+
+	return err
+}
+
+// SetDeadline sets a deadline t for pending and future calls to Accept. A zero
+// value for t means Accept will not time out.
+func (s *deadlineShim) SetDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadline = t
+	close(s.deadlineC)
+	s.deadlineC = make(chan struct{})
+	return nil
+}
+
+// accept accepts connections from s.ln until s is closed.
+func (s *deadlineShim) accept() {
+	for {
+		c, err := s.ln.Accept()
+
+		select {
+		case s.acceptC <- accept{c: c, err: err}:
+		case <-s.doneC:
+			// Nobody will receive this connection.
+			if c != nil {
+				_ = c.Close()
+			}
+
+			return
+		}
+	}
+}
+
+// opError wraps err in a *net.OpError for an accept operation.
+func (s *deadlineShim) opError(err error) error {
+	addr := s.ln.Addr()
+	return &net.OpError{
+		Op:   "accept",
+		Net:  addr.Network(),
+		Addr: addr,
+		Err:  err,
+	}
+}
@@ -0,0 +1,36 @@
+package multinet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// ListenTCPDual creates a Listener which aggregates TCP listeners bound to
+// port on all IPv4 and all IPv6 addresses. ctx is used only while binding the
+// listeners. If either bind fails, any listener already bound is closed and an
+// error is returned.
+//
+// If port is 0, the IPv4 listener is bound to an ephemeral port and the IPv6
+// listener is bound to the same port.
+func ListenTCPDual(ctx context.Context, port int) (*Listener, error) {
+	var lc net.ListenConfig
+
+	tcp4, err := lc.Listen(ctx, "tcp4", net.JoinHostPort("", strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("multinet: failed to listen on tcp4 port %d: %w", port, err)
+	}
+
+	if port == 0 {
+		port = tcp4.Addr().(*net.TCPAddr).Port
+	}
+
+	tcp6, err := lc.Listen(ctx, "tcp6", net.JoinHostPort("", strconv.Itoa(port)))
+	if err != nil {
+		_ = tcp4.Close()
+		return nil, fmt.Errorf("multinet: failed to listen on tcp6 port %d: %w", port, err)
+	}
+
+	return Listen(tcp4, tcp6), nil
+}
@@ -0,0 +1,80 @@
+package multinet_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+	"golang.org/x/net/nettest"
+)
+
+func TestListenTCPDual(t *testing.T) {
+	if !nettest.SupportsIPv4() || !nettest.SupportsIPv6() {
+		t.Skip("skipping, IPv4 and IPv6 are both required")
+	}
+
+	l, err := multinet.ListenTCPDual(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	addrs := l.Addrs()
+	if diff := cmp.Diff(2, len(addrs)); diff != "" {
+		t.Fatalf("unexpected number of addresses (-want +got):\n%s", diff)
+	}
+
+	var (
+		tcp4 = addrs[0].(*net.TCPAddr)
+		tcp6 = addrs[1].(*net.TCPAddr)
+	)
+
+	if diff := cmp.Diff(tcp4.Port, tcp6.Port); diff != "" {
+		t.Fatalf("unexpected tcp6 port (-want +got):\n%s", diff)
+	}
+
+	// Both families accept connections on the same port.
+	port := strconv.Itoa(tcp4.Port)
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		addr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			t.Fatalf("failed to resolve address: %v", err)
+		}
+
+		acceptOne(t, l, addr)
+	}
+
+	// Binding the same port again fails on the first listener.
+	if _, err := multinet.ListenTCPDual(context.Background(), tcp4.Port); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestListenTCPDualPartialFailure(t *testing.T) {
+	if !nettest.SupportsIPv4() || !nettest.SupportsIPv6() {
+		t.Skip("skipping, IPv4 and IPv6 are both required")
+	}
+
+	// Occupy a port for IPv6 only, so that the IPv4 bind succeeds and the IPv6
+	// bind fails.
+	tcp6, err := net.Listen("tcp6", "[::]:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer tcp6.Close()
+
+	port := tcp6.Addr().(*net.TCPAddr).Port
+	if _, err := multinet.ListenTCPDual(context.Background(), port); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	// The IPv4 listener must have been cleaned up.
+	tcp4, err := net.Listen("tcp4", net.JoinHostPort("", strconv.Itoa(port)))
+	if err != nil {
+		t.Fatalf("IPv4 listener was not closed: %v", err)
+	}
+	_ = tcp4.Close()
+}
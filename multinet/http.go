@@ -0,0 +1,39 @@
+package multinet
+
+import (
+	"net"
+	"net/http"
+)
+
+// HTTPServer creates a Listener which aggregates ls, as with Listen, and an
+// *http.Server which serves h. The caller starts the server by passing the
+// Listener to its Serve method:
+//
+//	srv, l := multinet.HTTPServer(h, ls...)
+//	go srv.Serve(l)
+//
+// The Listener is closed, as by Listener.Close rather than Listener.Shutdown,
+// at the following times:
+//
+//   - Once Serve has been called, the server's Shutdown and Close methods
+//     close the Listener, and in turn its net.Listeners, as they do for any
+//     net.Listener passed to Serve.
+//   - The server's Shutdown method also closes the Listener if Serve was never
+//     called. This occurs asynchronously, so callers which must await teardown
+//     can wait on Listener.Done.
+//   - The server's Close method does not close the Listener if Serve was never
+//     called, in which case the caller must call Close on the Listener.
+//
+// Shutdown stops accepting connections on every net.Listener, and the server
+// itself then waits for its active connections to become idle. Callers may
+// otherwise configure the returned *http.Server before calling Serve.
+func HTTPServer(h http.Handler, ls ...net.Listener) (*http.Server, *Listener) {
+	l := Listen(ls...)
+	srv := &http.Server{Handler: h}
+
+	// The server closes the Listeners passed to Serve on Shutdown, but also
+	// close l in case Serve was never called. Close is idempotent.
+	srv.RegisterOnShutdown(func() { _ = l.Close() })
+
+	return srv, l
+}
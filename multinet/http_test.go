@@ -0,0 +1,90 @@
+package multinet_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestHTTPServer(t *testing.T) {
+	var (
+		tcp  = localListener("tcp")
+		unix = localListener("unix")
+	)
+
+	srv, l := multinet.HTTPServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	}), tcp, unix)
+
+	errC := make(chan error, 1)
+	go func() { errC <- srv.Serve(l) }()
+
+	for _, addr := range l.Addrs() {
+		if diff := cmp.Diff("hello", httpGet(t, addr)); diff != "" {
+			t.Fatalf("unexpected response body (-want +got):\n%s", diff)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shut down server: %v", err)
+	}
+	if err := <-errC; !errors.Is(err, http.ErrServerClosed) {
+		t.Fatalf("unexpected serve error: %v", err)
+	}
+
+	// Shutting down the server closed the Listener and its net.Listeners.
+	waitDone(t, l)
+	if _, err := tcp.Accept(); err == nil {
+		t.Fatal("expected an error after Shutdown, but none occurred")
+	}
+}
+
+func TestHTTPServerShutdownWithoutServe(t *testing.T) {
+	tcp := localListener("tcp")
+	srv, l := multinet.HTTPServer(http.NotFoundHandler(), tcp)
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shut down server: %v", err)
+	}
+
+	waitDone(t, l)
+	if _, err := tcp.Accept(); err == nil {
+		t.Fatal("expected an error after Shutdown, but none occurred")
+	}
+}
+
+func TestHTTPServerCloseWithoutServe(t *testing.T) {
+	tcp := localListener("tcp")
+	srv, l := multinet.HTTPServer(http.NotFoundHandler(), tcp)
+
+	if err := srv.Close(); err != nil {
+		t.Fatalf("failed to close server: %v", err)
+	}
+
+	// Serve was never called, so the Listener remains open for the caller to
+	// close.
+	acceptOne(t, l, tcp.Addr())
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+}
+
+// waitDone waits for l to be closed, failing the test after a timeout.
+func waitDone(t *testing.T, l *multinet.Listener) {
+	t.Helper()
+
+	select {
+	case <-l.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Listener to close")
+	}
+}
@@ -0,0 +1,46 @@
+package multinet
+
+import (
+	"net"
+	"time"
+)
+
+// WithKeepAlive configures a Listener to set TCP keep-alive options on each
+// accepted *net.TCPConn, as net.ListenConfig.KeepAlive does when binding a
+// listener. If d is positive, keep-alives are enabled and d is the idle period
+// before keep-alive probes are sent. If d is zero, keep-alives are enabled
+// with the operating system's default period. If d is negative, keep-alives
+// are disabled.
+//
+// WithKeepAlive only affects connections of type *net.TCPConn, and is a no-op
+// for any other connection, such as those accepted by a *net.UnixListener. It
+// is applied before any wrappers added by WithConnTracking or WithConnWrapper.
+// Errors which occur while setting the options are ignored, as the connection
+// remains usable.
+//
+// Note that a listener created by net.Listen already enables keep-alives on
+// its accepted connections with a default period of 15 seconds.
+func WithKeepAlive(d time.Duration) Option {
+	return func(c *config) {
+		c.keepAlive = true
+		c.keepAlivePeriod = d
+	}
+}
+
+// setKeepAlive applies the keep-alive period d to c if it is a *net.TCPConn.
+func setKeepAlive(c net.Conn, d time.Duration) {
+	tc, ok := c.(*net.TCPConn)
+	if !ok {
+		return
+	}
+
+	if d < 0 {
+		_ = tc.SetKeepAlive(false)
+		return
+	}
+
+	_ = tc.SetKeepAlive(true)
+	if d > 0 {
+		_ = tc.SetKeepAlivePeriod(d)
+	}
+}
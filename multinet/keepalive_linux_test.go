@@ -0,0 +1,94 @@
+//go:build linux
+
+package multinet_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+	"golang.org/x/sys/unix"
+)
+
+func TestWithKeepAlive(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		on   int
+		idle int
+	}{
+		{
+			name: "disabled",
+			d:    -1,
+		},
+		{
+			name: "period",
+			d:    42 * time.Second,
+			on:   1,
+			idle: 42,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tcp = localListener("tcp")
+				uds = localListener("unix")
+				l   = multinet.NewListener([]net.Listener{tcp, uds}, multinet.WithKeepAlive(tt.d))
+			)
+			defer l.Close()
+
+			// Connections which are not TCP are accepted untouched.
+			acceptOne(t, l, uds.Addr())
+
+			c, err := net.Dial("tcp", tcp.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+
+			ac, err := l.Accept()
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+			defer ac.Close()
+
+			on, idle := keepAlive(t, ac.(*net.TCPConn))
+			if diff := cmp.Diff(tt.on, on); diff != "" {
+				t.Fatalf("unexpected SO_KEEPALIVE (-want +got):\n%s", diff)
+			}
+			if tt.on == 0 {
+				return
+			}
+
+			if diff := cmp.Diff(tt.idle, idle); diff != "" {
+				t.Fatalf("unexpected TCP_KEEPIDLE (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// keepAlive returns the SO_KEEPALIVE and TCP_KEEPIDLE socket options of c.
+func keepAlive(t *testing.T, c *net.TCPConn) (on, idle int) {
+	t.Helper()
+
+	rc, err := c.SyscallConn()
+	if err != nil {
+		t.Fatalf("failed to get raw conn: %v", err)
+	}
+
+	var onErr, idleErr error
+	if err := rc.Control(func(fd uintptr) {
+		on, onErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_KEEPALIVE)
+		idle, idleErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPIDLE)
+	}); err != nil {
+		t.Fatalf("failed to control raw conn: %v", err)
+	}
+	if onErr != nil || idleErr != nil {
+		t.Fatalf("failed to get socket options: %v, %v", onErr, idleErr)
+	}
+
+	return on, idle
+}
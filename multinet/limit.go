@@ -0,0 +1,46 @@
+package multinet
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// LimitListener returns a net.Listener which accepts at most n connections
+// from ln, such as for a one-shot service. Once n connections have been
+// accepted, Accept returns net.ErrClosed, so a Listener which owns the
+// returned net.Listener stops accepting from it and it no longer counts
+// toward Listener.Len. Errors returned by ln's Accept method do not count
+// toward n.
+//
+// ln is not closed when the limit is reached, and must still be closed by the
+// caller or by its owning Listener.
+func LimitListener(ln net.Listener, n int) net.Listener {
+	l := &limitListener{Listener: ln}
+	l.remaining.Store(int64(n))
+	return l
+}
+
+// A limitListener is a net.Listener created by LimitListener.
+type limitListener struct {
+	net.Listener
+	remaining atomic.Int64
+}
+
+// Accept implements net.Listener.
+func (l *limitListener) Accept() (net.Conn, error) {
+	// Reserve a slot before accepting so that concurrent callers cannot
+	// exceed the limit.
+	if l.remaining.Add(-1) < 0 {
+		l.remaining.Add(1)
+		return nil, net.ErrClosed
+	}
+
+	c, err := l.Listener.Accept()
+	if err != nil {
+		// Release the slot; only successful accepts count.
+		l.remaining.Add(1)
+		return nil, err
+	}
+
+	return c, nil
+}
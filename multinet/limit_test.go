@@ -0,0 +1,60 @@
+package multinet_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestLimitListener(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(multinet.LimitListener(tcp1, 2), tcp2)
+	)
+	defer l.Close()
+
+	acceptOne(t, l, tcp1.Addr())
+	acceptOne(t, l, tcp1.Addr())
+
+	// The limited listener stops accepting, but the other continues.
+	waitLen(t, l, 1)
+	acceptOne(t, l, tcp2.Addr())
+
+	var accepted []uint64
+	for _, s := range l.Stats() {
+		accepted = append(accepted, s.Accepted)
+	}
+
+	if diff := cmp.Diff([]uint64{2, 1}, accepted); diff != "" {
+		t.Fatalf("unexpected accepted counts (-want +got):\n%s", diff)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+}
+
+func TestLimitListenerOneShot(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(multinet.LimitListener(tcp, 1))
+	defer l.Close()
+
+	acceptOne(t, l, tcp.Addr())
+
+	if _, err := l.Accept(); !errors.Is(err, multinet.ErrAllListenersClosed) {
+		t.Fatalf("unexpected accept error: %v", err)
+	}
+}
+
+func TestLimitListenerZero(t *testing.T) {
+	tcp := localListener("tcp")
+	defer tcp.Close()
+
+	if _, err := multinet.LimitListener(tcp, 0).Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("unexpected accept error: %v", err)
+	}
+}
@@ -1,11 +1,15 @@
 package multinet
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,6 +31,101 @@ func (a Addr) String() string {
 	return a.join(func(addr net.Addr) string { return addr.String() })
 }
 
+// Filter returns the net.Addrs in a whose Network matches network.
+//
+// In addition to matching Network values such as "tcp" or "unix" exactly,
+// network may specify an IP family suffix such as "tcp4" or "udp6" to match
+// only the IPv4 or IPv6 addresses of that network, since package net reports
+// "tcp" as the Network for both families. An IP-based address with no IP,
+// such as a wildcard bind of ":8080", matches both families.
+func (a Addr) Filter(network string) Addr {
+	var out Addr
+	for _, addr := range a {
+		if matchNetwork(addr, network) {
+			out = append(out, addr)
+		}
+	}
+
+	return out
+}
+
+// ByNetwork groups the net.Addrs in a by their Network values. Use Filter to
+// further split IP-based networks by family.
+func (a Addr) ByNetwork() map[string]Addr {
+	m := make(map[string]Addr)
+	for _, addr := range a {
+		m[addr.Network()] = append(m[addr.Network()], addr)
+	}
+
+	return m
+}
+
+// Dedup returns a copy of a with duplicate net.Addrs removed, such as when
+// several net.Listeners share an address using SO_REUSEPORT. Two net.Addrs are
+// duplicates if their Network and String values are equal, and the first of
+// each is kept in its original order.
+func (a Addr) Dedup() Addr {
+	type key struct{ network, address string }
+
+	var (
+		out  = make(Addr, 0, len(a))
+		seen = make(map[key]struct{}, len(a))
+	)
+
+	for _, addr := range a {
+		k := key{network: addr.Network(), address: addr.String()}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+
+		seen[k] = struct{}{}
+		out = append(out, addr)
+	}
+
+	return out
+}
+
+// matchNetwork reports whether addr matches network as described in
+// Addr.Filter.
+func matchNetwork(addr net.Addr, network string) bool {
+	if addr.Network() == network {
+		return true
+	}
+
+	// Check for an IP family suffix.
+	var want4 bool
+	switch {
+	case strings.HasSuffix(network, "4"):
+		want4 = true
+	case strings.HasSuffix(network, "6"):
+	default:
+		return false
+	}
+
+	if addr.Network() != network[:len(network)-1] {
+		return false
+	}
+
+	var ip net.IP
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+	case *net.UDPAddr:
+		ip = addr.IP
+	case *net.IPAddr:
+		ip = addr.IP
+	default:
+		return false
+	}
+
+	if len(ip) == 0 {
+		// Wildcard, matches either family.
+		return true
+	}
+
+	return (ip.To4() != nil) == want4
+}
+
 // join invokes fn for each net.Addr stored in Addr and collects the results
 // into a comma-separated string.
 func (a Addr) join(fn func(addr net.Addr) string) string {
@@ -38,62 +137,805 @@ func (a Addr) join(fn func(addr net.Addr) string) string {
 	return strings.Join(ss, ",")
 }
 
+// errClosed is returned by Listener.Accept after the Listener is closed,
+// unless WithClosedError is used. It wraps net.ErrClosed.
+var errClosed error = &closedError{Err: errors.New("multinet: use of closed network connection")}
+
+// errAcceptTimeout is returned by Listener.AcceptTimeout when no connection
+// arrives in time.
+var errAcceptTimeout net.Error = &timeoutError{}
+
+// A timeoutError is a net.Error which reports a timeout. It wraps
+// os.ErrDeadlineExceeded, as do the errors returned by the net package when a
+// deadline is exceeded.
+type timeoutError struct{}
+
+func (*timeoutError) Error() string   { return "multinet: accept timed out" }
+func (*timeoutError) Timeout() bool   { return true }
+func (*timeoutError) Temporary() bool { return true }
+func (*timeoutError) Unwrap() error   { return os.ErrDeadlineExceeded }
+
+// A closedError is returned by Listener.Accept after the Listener is closed.
+// It reports the message of Err, but wraps both Err and net.ErrClosed.
+type closedError struct{ Err error }
+
+func (e *closedError) Error() string   { return e.Err.Error() }
+func (e *closedError) Unwrap() []error { return []error{e.Err, net.ErrClosed} }
+
+// ErrNoListeners is returned by Listener.Accept when the Listener does not own
+// any net.Listeners, which typically indicates a configuration error.
+var ErrNoListeners = errors.New("multinet: no net.Listeners added to Listener")
+
+// ErrAllListenersClosed is returned by Listener.Accept when every owned
+// net.Listener has failed permanently and no further connections can be
+// accepted, even though the Listener itself has not been closed. It wraps
+// net.ErrClosed.
+var ErrAllListenersClosed = fmt.Errorf("multinet: all net.Listeners have failed permanently: %w", net.ErrClosed)
+
+// An AcceptError is returned by Listener.Accept when one of its net.Listeners
+// returns an error from Accept, identifying which net.Listener failed.
+type AcceptError struct {
+	// Listener is the net.Listener which returned Err, and Name is its name
+	// if it was added to the Listener using Named.
+	Listener net.Listener
+	Name     string
+
+	// Err is the error returned by Listener's Accept method.
+	Err error
+}
+
+var _ net.Error = &AcceptError{}
+
+// Error implements error, returning the message of the underlying error
+// prefixed by the name of the net.Listener, if any.
+func (e *AcceptError) Error() string {
+	if e.Name == "" {
+		return e.Err.Error()
+	}
+
+	return e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *AcceptError) Unwrap() error { return e.Err }
+
+// Timeout implements net.Error, reporting whether the underlying error is a
+// timeout.
+func (e *AcceptError) Timeout() bool { return isTimeout(e.Err) }
+
+// Temporary implements net.Error, reporting whether the underlying error is
+// temporary.
+func (e *AcceptError) Temporary() bool {
+	var ne interface{ Temporary() bool }
+	return errors.As(e.Err, &ne) && ne.Temporary()
+}
+
+// An Option configures a Listener or PacketConn. Options which do not apply
+// to a given type are ignored.
+type Option func(*config)
+
+// config stores the configuration applied by Options.
+type config struct {
+	pull    bool
+	track   bool
+	budget  int
+	primary net.Listener
+	route   func(dst net.Addr) net.PacketConn
+	wraps   map[net.Listener]func(net.Conn) net.Conn
+	ctx     func(net.Conn) context.Context
+	noClose bool
+	dedup   bool
+	skip    bool
+	closed  error
+
+	keepAlive       bool
+	keepAlivePeriod time.Duration
+	latency         bool
+}
+
+// WithPullMode configures a Listener to only call Accept on its net.Listeners
+// when the caller is waiting in Listener.Accept.
+//
+// By default, each net.Listener is accepted from eagerly and the resulting
+// connections are buffered until the caller invokes Listener.Accept. In pull
+// mode, at most one connection per net.Listener is accepted ahead of demand,
+// leaving any others queued by the kernel until the caller is ready.
+func WithPullMode() Option {
+	return func(c *config) { c.pull = true }
+}
+
+// WithoutListenerClose configures a Listener to leave its net.Listeners open
+// when the Listener is closed or when a net.Listener is removed by
+// WithErrorBudget, so that the caller can continue to use them, such as with
+// another Listener. By default, the Listener closes all of its net.Listeners.
+//
+// When the Listener is closed, a pending Accept on each net.Listener which
+// supports deadlines is interrupted by setting a deadline in the past, and the
+// deadline is cleared once its accept goroutine has exited. A net.Listener
+// without deadline support, such as one not wrapped by WithDeadlineShim,
+// delays Close until its pending Accept returns. In either case, a connection
+// accepted while the Listener is closing is closed rather than returned.
+//
+// The caller remains responsible for closing each net.Listener to avoid file
+// descriptor leaks.
+func WithoutListenerClose() Option {
+	return func(c *config) { c.noClose = true }
+}
+
+// WithErrorBudget configures a Listener to tolerate up to n consecutive
+// errors from a single net.Listener's Accept method before removing that
+// net.Listener from service, so that one failing net.Listener cannot spin or
+// terminate a server which is otherwise healthy. The count is reset whenever
+// the net.Listener accepts a connection.
+//
+// Timeout errors are forwarded to Listener.Accept as usual, but all other
+// errors are counted in ListenerStats.Errors rather than forwarded. Once the
+// budget is exhausted, the net.Listener is closed, it no longer counts toward
+// Listener.Len, and ListenerStats.Removed is set. If n is 0 or less, errors
+// are always forwarded.
+func WithErrorBudget(n int) Option {
+	return func(c *config) { c.budget = n }
+}
+
+// WithClosedError configures a Listener to return err from Accept once the
+// Listener is closed, such as a framework-specific error which carries context
+// about the shutdown. The error returned by Accept has the same message as
+// err and wraps err, so errors.Is and errors.As match err, but it also wraps
+// net.ErrClosed, so errors.Is(err, net.ErrClosed) continues to report true.
+//
+// By default, Accept returns an error which wraps net.ErrClosed once the
+// Listener is closed. WithClosedError has no effect if err is nil.
+func WithClosedError(err error) Option {
+	return func(c *config) { c.closed = err }
+}
+
+// WithDedupAddr configures a Listener to remove duplicate addresses from the
+// Addr returned by its Addr method, as described by Addr.Dedup. This is useful
+// for logging and service registration when several net.Listeners share an
+// address, such as those created by ListenSharded. Listener.Addrs continues to
+// return one address per net.Listener.
+func WithDedupAddr() Option {
+	return func(c *config) { c.dedup = true }
+}
+
+// WithPrimary configures a Listener to report the address of ln from its Addr
+// method, rather than the aggregated addresses of all of its net.Listeners.
+// This is useful when a single address is displayed in logs or health checks.
+// Connections are still accepted from all net.Listeners, and the aggregated
+// addresses remain available from Listener.Addrs.
+//
+// ln must be one of the net.Listeners passed to the Listener, or the option
+// has no effect.
+func WithPrimary(ln net.Listener) Option {
+	return func(c *config) { c.primary = ln }
+}
+
+// Named labels ln with a human-readable name for use with Listen or
+// NewListener, such as "public" or "admin", to tell apart net.Listeners whose
+// addresses look alike. The name is reported by ListenerStats and
+// AcceptError.
+//
+// The Listener unwraps the returned net.Listener, so ln is used directly for
+// Accept, SetDeadline, and so on. Options which refer to a net.Listener, such
+// as WithPrimary, must refer to the net.Listener returned by Named.
+func Named(name string, ln net.Listener) net.Listener {
+	return &namedListener{Listener: ln, name: name}
+}
+
+// A namedListener is a net.Listener labeled by Named.
+type namedListener struct {
+	net.Listener
+	name string
+}
+
+// WithConnWrapper configures a Listener to call wrap on each net.Conn accepted
+// from ln before it is returned by Listener.Accept, such as to apply rate
+// limiting or TLS to the connections of a single net.Listener. ln must be one
+// of the net.Listeners passed to the Listener, and connections accepted from
+// other net.Listeners are unaffected.
+//
+// If WithConnWrapper is used more than once for the same ln, the wrappers are
+// applied in the order they were specified.
+func WithConnWrapper(ln net.Listener, wrap func(net.Conn) net.Conn) Option {
+	return func(c *config) {
+		if c.wraps == nil {
+			c.wraps = make(map[net.Listener]func(net.Conn) net.Conn)
+		}
+
+		prev, ok := c.wraps[ln]
+		if !ok {
+			c.wraps[ln] = wrap
+			return
+		}
+
+		c.wraps[ln] = func(c net.Conn) net.Conn { return wrap(prev(c)) }
+	}
+}
+
 // A Listener is a net.Listener which aggregates multiple net.Listeners. The
 // net.Listeners do not have to be of the same underlying type. Any connection
-// or error from an individual net.Listener will be forwarded to the Listener.
+// or error from an individual net.Listener will be forwarded to the Listener,
+// with errors wrapped in an *AcceptError, except for an error indicating that
+// the net.Listener was closed. Such a net.Listener has failed permanently and
+// is no longer accepted from, but the Listener continues to serve connections
+// from its other net.Listeners. Once every net.Listener has failed
+// permanently, Accept returns ErrAllListenersClosed.
 type Listener struct {
-	ls                    []net.Listener
+	cfg                   config
 	acceptOnce, closeOnce sync.Once
-	wg                    sync.WaitGroup
 	doneC                 chan struct{}
 	acceptC               chan accept
+
+	// mu guards ls and the fields below. ls is in the order that its
+	// net.Listeners were added, and is appended to by Add and replaced by
+	// Remove, but never modified in place. Once doneC is closed, ls is no
+	// longer modified.
+	mu      sync.RWMutex
+	ls      []*listener
+	primary *listener
+	started bool
+
+	// tierLive is the number of net.Listeners in each tier which have not
+	// failed permanently, and tier is the index of the tier currently being
+	// accepted from. Only ListenPrioritized creates more than one tier.
+	tierLive []int
+	tier     int
+
+	// live is the number of net.Listeners which have not failed permanently,
+	// and deadC is closed when live reaches zero.
+	live  atomic.Int64
+	deadC chan struct{}
+
+	// closedC is closed when teardown completes, at which point closeErr
+	// holds the first error returned by an owned net.Listener's Close.
+	closedC  chan struct{}
+	closeErr error
+
+	// acceptClosedErr is returned by Accept once the Listener is closed.
+	acceptClosedErr error
+
+	// acceptsErr is the error which stopped the most recent iterator
+	// returned by Accepts, and is guarded by mu.
+	acceptsErr error
+
+	// Pull mode: the number of callers waiting in Accept, and a condition
+	// variable to wake accept goroutines when that number changes.
+	pullMu   sync.Mutex
+	pullCond *sync.Cond
+	waiting  int
+
+	// pauseMu guards pausedC and resumeC. While the Listener is not paused,
+	// pausedC is closed by Pause and resumeC is nil. While it is paused,
+	// resumeC is closed by Resume.
+	pauseMu          sync.Mutex
+	pausedC, resumeC chan struct{}
 }
 
 var _ net.Listener = &Listener{}
 
+// A listener is a net.Listener owned by a Listener.
+type listener struct {
+	net.Listener
+	name string
+
+	// wrap, if set, is applied to each accepted net.Conn.
+	wrap func(net.Conn) net.Conn
+
+	// tier is the priority tier of the net.Listener, and standby is set when
+	// the net.Listener is not accepted from until its tier is activated.
+	// standby is guarded by the owning Listener's mu.
+	tier    int
+	standby bool
+
+	// removed is set when the error budget is exhausted and the net.Listener
+	// has been closed by its accept goroutine.
+	removed atomic.Bool
+
+	// failed is set once the net.Listener has been counted as failed
+	// permanently by dead, and is guarded by the owning Listener's mu.
+	failed bool
+
+	stats
+
+	// closeC is closed when Close returns, and exitC is closed when the
+	// accept goroutine for this net.Listener exits or will never start.
+	// removeC is closed by Listener.Remove to stop the accept goroutine.
+	closeC, exitC, removeC chan struct{}
+}
+
 // Listen creates a Listener which aggregates multiple net.Listeners. Although
 // it is possible to construct a Listener with no net.Listeners, it will always
-// return an error on Accept.
-func Listen(ls ...net.Listener) *Listener {
-	return &Listener{
-		ls:      ls,
-		doneC:   make(chan struct{}),
-		acceptC: make(chan accept, len(ls)),
+// return ErrNoListeners on Accept until a net.Listener is added.
+//
+// If the same net.Listener is passed more than once, only its first
+// occurrence is used.
+func Listen(ls ...net.Listener) *Listener { return NewListener(ls) }
+
+// ListenRequire is like Listen, but returns ErrNoListeners if no
+// net.Listeners are passed, so that a misconfigured service fails at startup
+// rather than on its first call to Accept. Listen remains available for
+// aggregates which are populated later using Add.
+func ListenRequire(ls ...net.Listener) (*Listener, error) {
+	if len(ls) == 0 {
+		return nil, ErrNoListeners
+	}
+
+	return Listen(ls...), nil
+}
+
+// ListenContext is like Listen, but ties the lifetime of the Listener to ctx.
+// When ctx is canceled, the Listener is closed as if by Close, but it remains
+// valid for inspection by methods such as Addr and Len. Close may still be
+// called to wait for teardown to complete and retrieve any error.
+func ListenContext(ctx context.Context, ls ...net.Listener) *Listener {
+	l := NewListener(ls)
+	if ctx.Done() == nil {
+		// ctx can never be canceled.
+		return l
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.close()
+		case <-l.doneC:
+			// Closed by the caller, nothing to do.
+		}
+	}()
+
+	return l
+}
+
+// NewListener creates a Listener which aggregates multiple net.Listeners,
+// using the input Options to configure the Listener. See Listen for details.
+func NewListener(ls []net.Listener, opts ...Option) *Listener {
+	return newTieredListener([][]net.Listener{ls}, opts)
+}
+
+// ListenPrioritized creates a Listener which aggregates tiers of
+// net.Listeners for active/standby failover, using the input Options to
+// configure the Listener. Initially, only the net.Listeners in the first
+// non-empty tier are accepted from. Once every net.Listener in that tier has
+// failed permanently, as described by Listener, the net.Listeners in the next
+// non-empty tier are accepted from, and so on.
+//
+// Failover is one-way: a lower tier remains active even if a net.Listener is
+// later added to the Listener using Add, which adds it to the tier currently
+// being accepted from. net.Listeners in standby count toward Listener.Len and
+// are reported by methods such as Addrs and Stats, and all tiers are closed by
+// Close.
+func ListenPrioritized(tiers [][]net.Listener, opts ...Option) *Listener {
+	return newTieredListener(tiers, opts)
+}
+
+// newTieredListener creates a Listener from tiers of net.Listeners.
+func newTieredListener(tiers [][]net.Listener, opts []Option) *Listener {
+	var cfg config
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	if len(tiers) == 0 {
+		tiers = [][]net.Listener{nil}
+	}
+
+	l := &Listener{
+		cfg:             cfg,
+		acceptClosedErr: errClosed,
+		doneC:           make(chan struct{}),
+		deadC:           make(chan struct{}),
+		closedC:         make(chan struct{}),
+		pausedC:         make(chan struct{}),
+		tierLive:        make([]int, len(tiers)),
+		tier:            -1,
+	}
+
+	l.pullCond = sync.NewCond(&l.pullMu)
+
+	if cfg.closed != nil {
+		l.acceptClosedErr = &closedError{Err: cfg.closed}
+	}
+
+	for i, ls := range tiers {
+		for _, ln := range ls {
+			if l.owns(ln) {
+				// Accepting from or closing the same net.Listener twice is
+				// a mistake, so only its first occurrence is used.
+				continue
+			}
+
+			if l.tier == -1 {
+				// The first non-empty tier is active.
+				l.tier = i
+			}
+
+			lln := l.newListener(ln)
+			lln.tier, lln.standby = i, l.tier != i
+			l.ls = append(l.ls, lln)
+			l.tierLive[i]++
+		}
+	}
+	if l.tier == -1 {
+		l.tier = 0
+	}
+
+	n := len(l.ls)
+	l.live.Store(int64(n))
+
+	if cfg.pull {
+		// Each accept goroutine holds at most one connection while waiting
+		// for the caller, so no further buffering is necessary.
+		l.acceptC = make(chan accept)
+	} else {
+		l.acceptC = make(chan accept, n)
 	}
+
+	return l
+}
+
+// newListener prepares ln to be owned by l, applying the configured Options.
+func (l *Listener) newListener(ln net.Listener) *listener {
+	var wrap func(net.Conn) net.Conn
+	if len(l.cfg.wraps) > 0 {
+		// Only consult the map when necessary, as a net.Listener of a
+		// non-comparable type cannot be used as a key.
+		wrap = l.cfg.wraps[ln]
+	}
+
+	lln := &listener{
+		Listener: ln,
+		wrap:     wrap,
+		closeC:   make(chan struct{}),
+		exitC:    make(chan struct{}),
+		removeC:  make(chan struct{}),
+	}
+
+	if nl, ok := ln.(*namedListener); ok {
+		lln.Listener, lln.name = nl.Listener, nl.name
+	}
+
+	if l.cfg.primary != nil && l.primary == nil && ln == l.cfg.primary {
+		l.primary = lln
+	}
+
+	return lln
+}
+
+// owns reports whether ln, or the net.Listener it wraps if it was created by
+// Named, is already owned by l. The caller must hold l.mu or have exclusive
+// access to l.
+func (l *Listener) owns(ln net.Listener) bool { return l.index(ln) != -1 }
+
+// index returns the index of ln, or the net.Listener it wraps if it was
+// created by Named, within l.ls, or -1 if l does not own it. The caller must
+// hold l.mu or have exclusive access to l.
+func (l *Listener) index(ln net.Listener) int {
+	if nl, ok := ln.(*namedListener); ok {
+		ln = nl.Listener
+	}
+
+	for i, lln := range l.ls {
+		if sameListener(lln.Listener, ln) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// sameListener reports whether a and b are the same net.Listener, without
+// panicking if their dynamic type is not comparable.
+func sameListener(a, b net.Listener) bool {
+	ta := reflect.TypeOf(a)
+	if ta == nil || ta != reflect.TypeOf(b) || !ta.Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// Add adds ln to the net.Listeners owned by this Listener. If Accept was
+// already called, ln is accepted from immediately, and otherwise it is
+// accepted from along with the other net.Listeners on the first call to
+// Accept. Add returns an error if the Listener is closed or already owns ln.
+func (l *Listener) Add(ln net.Listener) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if isClosed(l.doneC) {
+		return errClosed
+	}
+	if l.owns(ln) {
+		return fmt.Errorf("multinet: net.Listener %s is already owned by this Listener", ln.Addr())
+	}
+
+	lln := l.newListener(ln)
+	lln.tier = l.tier
+	l.ls = append(l.ls, lln)
+	l.tierLive[l.tier]++
+
+	if l.live.Add(1) == 1 && isClosed(l.deadC) {
+		// All of the previous net.Listeners failed, but this one has revived
+		// the Listener.
+		l.deadC = make(chan struct{})
+	}
+
+	if l.started {
+		go l.run(lln)
+	}
+
+	return nil
+}
+
+// Remove removes ln from the net.Listeners owned by this Listener, stops
+// accepting from it, and closes it, unless WithoutListenerClose is used. The
+// relative order of the remaining net.Listeners is preserved, as reported by
+// methods such as Addrs and Stats. If ln was the primary net.Listener set by
+// WithPrimary, Addr reports the addresses of all owned net.Listeners from then
+// on. A connection accepted from ln before it was removed may still be
+// returned by Accept.
+//
+// Remove returns an error if the Listener is closed or does not own ln, and
+// otherwise returns any error from closing ln.
+func (l *Listener) Remove(ln net.Listener) error {
+	l.mu.Lock()
+
+	if isClosed(l.doneC) {
+		l.mu.Unlock()
+		return errClosed
+	}
+
+	i := l.index(ln)
+	if i == -1 {
+		l.mu.Unlock()
+		return fmt.Errorf("multinet: net.Listener %s is not owned by this Listener", ln.Addr())
+	}
+
+	// Replace rather than modify l.ls so that snapshots returned by
+	// listeners remain valid.
+	lln := l.ls[i]
+	ls := make([]*listener, 0, len(l.ls)-1)
+	ls = append(ls, l.ls[:i]...)
+	l.ls = append(ls, l.ls[i+1:]...)
+
+	if l.primary == lln {
+		l.primary = nil
+	}
+
+	if !l.started || lln.standby {
+		// No accept goroutine was started, and none will be now that lln is
+		// no longer in l.ls.
+		close(lln.exitC)
+	}
+	l.mu.Unlock()
+
+	// Stop the accept goroutine, waking it if it is waiting on demand in pull
+	// mode, and release ln.
+	close(lln.removeC)
+	l.pullMu.Lock()
+	l.pullCond.Broadcast()
+	l.pullMu.Unlock()
+
+	var err error
+	if l.cfg.noClose {
+		err = lln.interrupt()
+	} else {
+		if !lln.removed.Load() {
+			err = lln.Close()
+		}
+		close(lln.closeC)
+		<-lln.exitC
+	}
+
+	l.dead(lln)
+	return err
 }
 
 // Accept accepts a net.Conn from one of the owned net.Listeners.
 func (l *Listener) Accept() (net.Conn, error) {
-	if len(l.ls) == 0 {
+	a := l.next(nil)
+	return a.c, a.err
+}
+
+// AcceptTimeout is like Accept, but returns a net.Error whose Timeout method
+// reports true if no connection or error is available within d. The error
+// also wraps os.ErrDeadlineExceeded. Unlike SetDeadline, the timeout applies
+// only to this call: the owned net.Listeners are not modified or closed, and
+// later calls to Accept or AcceptTimeout are unaffected.
+//
+// If d is zero or negative, AcceptTimeout returns a result which is already
+// available, and otherwise times out immediately.
+func (l *Listener) AcceptTimeout(d time.Duration) (net.Conn, error) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	a := l.next(timer.C)
+	return a.c, a.err
+}
+
+// AcceptFrom is like Accept, but also returns the network of the owned
+// net.Listener which produced the net.Conn or error, such as "tcp" or "unix",
+// so the caller can branch on the transport of the connection. If the error
+// does not originate from an owned net.Listener, such as when the Listener is
+// closed, the network is empty.
+func (l *Listener) AcceptFrom() (net.Conn, string, error) {
+	a := l.next(nil)
+	if a.ln == nil {
+		return a.c, "", a.err
+	}
+
+	return a.c, a.ln.Addr().Network(), a.err
+}
+
+// next returns the next result from the owned net.Listeners for Accept. If
+// timeoutC is not nil and receives before a result is available, next returns
+// errAcceptTimeout.
+func (l *Listener) next(timeoutC <-chan time.Time) accept {
+	l.mu.RLock()
+	n, deadC := len(l.ls), l.deadC
+	l.mu.RUnlock()
+
+	if n == 0 {
 		// No listeners, nothing to do.
-		return nil, errors.New("multinet: no net.Listeners added to Listener")
+		return accept{err: ErrNoListeners}
 	}
 
 	l.acceptOnce.Do(func() {
 		// On first Accept, create accept multiplexing goroutines which will
-		// feed accepted connections and errors over l.acceptC.
-		l.wg.Add(len(l.ls))
+		// feed accepted connections and errors over l.acceptC. Any
+		// net.Listeners added later start their own goroutines.
+		l.mu.Lock()
+		defer l.mu.Unlock()
 
+		l.started = true
 		for _, ln := range l.ls {
-			go func(ln net.Listener) {
-				defer l.wg.Done()
-				l.accept(ln)
-			}(ln)
+			if !ln.standby {
+				go l.run(ln)
+			}
 		}
 	})
 
-	select {
-	case a := <-l.acceptC:
-		return a.c, a.err
-	case <-l.doneC:
-		// TODO: good enough?
-		return nil, errors.New("multinet: use of closed network connection")
+	if isClosed(l.doneC) {
+		// Never return connections buffered before the Listener was closed.
+		return accept{err: l.acceptClosedErr}
+	}
+
+	// received is set once a result is received from an accept goroutine.
+	var received bool
+
+	if l.cfg.pull {
+		// Signal demand for a connection to the accept goroutines until this
+		// caller receives one.
+		l.pullMu.Lock()
+		l.waiting++
+		l.pullMu.Unlock()
+		l.pullCond.Broadcast()
+
+		// The accept goroutine which sends a result is responsible for
+		// decrementing the waiting count, so withdraw the demand on any other
+		// return, such as when the Listener is closed or the deadline passes.
+		defer func() {
+			if received {
+				return
+			}
+
+			l.pullMu.Lock()
+			l.waiting--
+			l.pullMu.Unlock()
+		}()
+	}
+
+	for {
+		pausedC, resumeC := l.pauseState()
+		if resumeC != nil {
+			// Paused: deliver nothing until resumed.
+			select {
+			case <-resumeC:
+				continue
+			case <-l.doneC:
+				return accept{err: l.acceptClosedErr}
+			case <-timeoutC:
+				return accept{err: errAcceptTimeout}
+			}
+		}
+
+		select {
+		case a := <-l.acceptC:
+			received = true
+			return l.deliver(a)
+		case <-pausedC:
+			// Paused while waiting, so wait for Resume.
+			continue
+		case <-l.doneC:
+			return accept{err: l.acceptClosedErr}
+		case <-timeoutC:
+			// A result which became available at the same time as the
+			// timeout takes priority.
+			select {
+			case a := <-l.acceptC:
+				received = true
+				return l.deliver(a)
+			default:
+				return accept{err: errAcceptTimeout}
+			}
+		case <-deadC:
+			// Every accept goroutine has exited, but results sent before they
+			// did may still be buffered and take priority.
+			select {
+			case a := <-l.acceptC:
+				received = true
+				return l.deliver(a)
+			default:
+				return accept{err: ErrAllListenersClosed}
+			}
+		}
+	}
+}
+
+// deliver returns a to a caller of Accept unless the Listener was closed
+// while a was ready, in which case a select may have chosen a over doneC at
+// random. No connection is returned once Close begins, so a.c is closed.
+func (l *Listener) deliver(a accept) accept {
+	if isClosed(l.doneC) {
+		closeConn(a.c)
+		return accept{err: l.acceptClosedErr}
 	}
+
+	if l.cfg.latency && a.err == nil {
+		a.ln.observeLatency(time.Since(a.at))
+	}
+
+	return a
 }
 
 // Addr creates a net.Addr of type Addr with all the aggregated addresses of
-// the owned net.Listeners.
+// the owned net.Listeners, in the order they were added to the Listener,
+// regardless of the order in which they accept connections. Removing a
+// net.Listener using Remove preserves the relative order of the others. If
+// WithPrimary was used, Addr instead returns the address of the primary
+// net.Listener.
 func (l *Listener) Addr() net.Addr {
+	l.mu.RLock()
+	primary := l.primary
+	l.mu.RUnlock()
+
+	if primary != nil {
+		return primary.Addr()
+	}
+
+	if l.cfg.dedup {
+		return l.Addrs().Dedup()
+	}
+
+	return l.Addrs()
+}
+
+// Addrs returns all the aggregated addresses of the owned net.Listeners in the
+// same order as Addr, regardless of whether WithPrimary was used.
+func (l *Listener) Addrs() Addr {
+	ls := l.listeners()
+	addrs := make(Addr, 0, len(ls))
+	for _, ln := range ls {
+		addrs = append(addrs, ln.Addr())
+	}
+
+	return addrs
+}
+
+// AddrSnapshot returns the aggregated addresses of the owned net.Listeners as
+// of a single point in time, as with Addrs, while holding the lock which
+// guards the set of net.Listeners. The snapshot is consistent with respect to
+// concurrent calls to methods such as Add which modify that set: it contains
+// exactly the addresses of the net.Listeners owned when it was taken. The
+// returned Addr is a copy which the caller may retain or modify.
+//
+// For a Listener whose set of net.Listeners does not change after
+// construction, Addr and Addrs are equivalent and need not take the lock for
+// as long.
+func (l *Listener) AddrSnapshot() Addr {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	addrs := make(Addr, 0, len(l.ls))
 	for _, ln := range l.ls {
 		addrs = append(addrs, ln.Addr())
@@ -102,6 +944,100 @@ func (l *Listener) Addr() net.Addr {
 	return addrs
 }
 
+// ListenerFor returns the owned net.Listener which is bound to the local
+// address local, such as the LocalAddr of a net.Conn accepted by the Listener.
+// An exact address match is preferred, but a net.Listener bound to a wildcard
+// address with the same port will also match: 0.0.0.0 matches any local IPv4
+// address, and :: matches any local IPv4 or IPv6 address, as with a
+// dual-stack socket. A nil local address matches no net.Listener.
+func (l *Listener) ListenerFor(local net.Addr) (net.Listener, bool) {
+	if local == nil {
+		return nil, false
+	}
+
+	var wildcard net.Listener
+	for _, ln := range l.listeners() {
+		addr := ln.Addr()
+		if addr.Network() != local.Network() {
+			continue
+		}
+
+		if addr.String() == local.String() {
+			return ln.Listener, true
+		}
+
+		if wildcard == nil && matchWildcard(addr, local) {
+			wildcard = ln.Listener
+		}
+	}
+
+	return wildcard, wildcard != nil
+}
+
+// FindListener returns the first owned net.Listener, in the order they were
+// added to the Listener, for which match returns true, such as to retrieve a
+// *net.TCPListener by type assertion to configure its socket. net.Listeners
+// labeled by Named are passed to match unwrapped.
+func (l *Listener) FindListener(match func(net.Listener) bool) (net.Listener, bool) {
+	for _, ln := range l.listeners() {
+		if match(ln.Listener) {
+			return ln.Listener, true
+		}
+	}
+
+	return nil, false
+}
+
+// matchWildcard reports whether the wildcard bind address addr contains the
+// local address local.
+func matchWildcard(addr, local net.Addr) bool {
+	ip, port, ok := ipPort(addr)
+	if !ok || !(len(ip) == 0 || ip.IsUnspecified()) {
+		return false
+	}
+
+	lip, lport, ok := ipPort(local)
+	if !ok || port != lport {
+		return false
+	}
+
+	if ip.To4() != nil {
+		// 0.0.0.0 only matches IPv4.
+		return lip.To4() != nil
+	}
+
+	return true
+}
+
+// ipPort returns the IP and port of a non-nil IP-based net.Addr.
+func ipPort(addr net.Addr) (net.IP, int, bool) {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		if addr == nil {
+			return nil, 0, false
+		}
+		return addr.IP, addr.Port, true
+	case *net.UDPAddr:
+		if addr == nil {
+			return nil, 0, false
+		}
+		return addr.IP, addr.Port, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// Len returns the number of net.Listeners owned by this Listener which are
+// still accepting connections. A net.Listener stops accepting connections
+// when it fails permanently, and Len returns 0 once the Listener is closed.
+func (l *Listener) Len() int {
+	if isClosed(l.doneC) {
+		return 0
+	}
+
+	return int(l.live.Load())
+}
+
 // A deadlineListener is a net.Listener with deadline support.
 type deadlineListener interface {
 	net.Listener
@@ -113,11 +1049,12 @@ type deadlineListener interface {
 // or an error will be returned. If more than one net.Listener returns an error,
 // only the first error is returned.
 func (l *Listener) SetDeadline(t time.Time) error {
-	dls := make([]deadlineListener, 0, len(l.ls))
-	for _, ln := range l.ls {
-		dl, ok := ln.(deadlineListener)
+	ls := l.listeners()
+	dls := make([]deadlineListener, 0, len(ls))
+	for _, ln := range ls {
+		dl, ok := ln.Listener.(deadlineListener)
 		if !ok {
-			return fmt.Errorf("multinet: net.Listener %T does not have a SetDeadline method", ln)
+			return fmt.Errorf("multinet: net.Listener %T does not have a SetDeadline method", ln.Listener)
 		}
 
 		dls = append(dls, dl)
@@ -134,52 +1071,484 @@ func (l *Listener) SetDeadline(t time.Time) error {
 	return err
 }
 
-// Close closes all net.Listeners owned by this Listener. If more than one
-// net.Listener returns an error, only the first error is returned.
+// SetDeadlineFor sets a deadline t on the net.Listener added to this Listener
+// with the specified name using Named, such as to unblock a single endpoint by
+// setting a deadline in the past. It returns an error if no net.Listener has
+// that name or if it does not support the method
+// "SetDeadline(t time.Time) error". If multiple net.Listeners share the name,
+// the first is used.
+func (l *Listener) SetDeadlineFor(name string, t time.Time) error {
+	for _, ln := range l.listeners() {
+		if ln.name != name {
+			continue
+		}
+
+		dl, ok := ln.Listener.(deadlineListener)
+		if !ok {
+			return fmt.Errorf("multinet: net.Listener %q (%T) does not have a SetDeadline method", name, ln.Listener)
+		}
+
+		return dl.SetDeadline(t)
+	}
+
+	return fmt.Errorf("multinet: no net.Listener named %q", name)
+}
+
+// SetDeadlineBestEffort sets a deadline t on all net.Listeners owned by this
+// Listener which support the method "SetDeadline(t time.Time) error", skipping
+// any which do not. Unlike SetDeadline, every error is reported: the returned
+// slice has one element per net.Listener, in the order they were added to the
+// Listener, which is nil for a net.Listener that was skipped or succeeded.
+func (l *Listener) SetDeadlineBestEffort(t time.Time) []error {
+	ls := l.listeners()
+	errs := make([]error, len(ls))
+	for i, ln := range ls {
+		if dl, ok := ln.Listener.(deadlineListener); ok {
+			errs[i] = dl.SetDeadline(t)
+		}
+	}
+
+	return errs
+}
+
+// Close closes all net.Listeners owned by this Listener, unless
+// WithoutListenerClose is used, and waits for their accept goroutines to
+// exit. If more than one net.Listener returns an error,
+// only the first error is returned.
+//
+// Close proceeds in order. First, Accept stops returning connections and the
+// owned net.Listeners are closed, so that the operating system stops
+// completing new connections on their behalf. Once every accept goroutine has
+// exited, any connections which were accepted but never returned by Accept are
+// closed. Finally, the Listener is released and Done is closed.
 func (l *Listener) Close() error {
-	var err error
+	first := l.close()
+	<-l.closedC
+
+	if !first {
+		return nil
+	}
+
+	return l.closeErr
+}
+
+// CloseTimeout is like Close, but waits at most d for the owned net.Listeners
+// to close and for their accept goroutines to exit. If d elapses first, an
+// error identifying the net.Listeners which did not close in time is returned
+// and the remaining teardown continues in the background.
+func (l *Listener) CloseTimeout(d time.Duration) error {
+	first := l.close()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-l.closedC:
+		if !first {
+			return nil
+		}
+
+		return l.closeErr
+	case <-timer.C:
+	}
+
+	var addrs []string
+	for _, ln := range l.ls {
+		if !isClosed(ln.closeC) || !isClosed(ln.exitC) {
+			addrs = append(addrs, ln.Addr().String())
+		}
+	}
+
+	return fmt.Errorf("multinet: timed out after %s waiting for net.Listeners to close: %s",
+		d, strings.Join(addrs, ","))
+}
+
+// Done returns a channel which is closed once the Listener has been closed and
+// its teardown is complete: all owned net.Listeners are closed and all accept
+// goroutines have exited. Done does not begin closing the Listener.
+//
+// Close returns only once Done is closed, but CloseTimeout may return before
+// its background teardown completes, so callers which must await full
+// teardown can wait on Done.
+func (l *Listener) Done() <-chan struct{} { return l.closedC }
+
+// Shutdown closes the Listener as with Close, and then waits for the
+// connections it returned from Accept to be closed by the caller, bounding a
+// graceful shutdown. If ctx is canceled first, any connections which remain
+// open are closed forcibly and Shutdown returns the context's error. As with
+// Close, no connection is returned by Accept once Shutdown begins, and
+// connections which were accepted but never returned by Accept are closed.
+//
+// Open connections are only known when WithConnTracking is used. Otherwise,
+// Shutdown returns once the Listener is closed.
+func (l *Listener) Shutdown(ctx context.Context) error {
+	err := l.Close()
+	if !l.cfg.track {
+		return err
+	}
+
+	ls := l.listeners()
+	active := func() bool {
+		for _, ln := range ls {
+			if ln.active.Load() > 0 {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for active() {
+		select {
+		case <-ctx.Done():
+			for _, ln := range ls {
+				ln.closeAll()
+			}
+
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return err
+}
+
+// shutdownPollInterval is how often Shutdown checks for open connections.
+const shutdownPollInterval = 10 * time.Millisecond
+
+// close begins closing the Listener, reporting whether this was the first call.
+func (l *Listener) close() bool {
+	var first bool
 
 	l.closeOnce.Do(func() {
-		// On first invocation of Close, halt all accept multiplexing
+		// On first invocation of close, halt all accept multiplexing
 		// goroutines and Close the individual listeners.
-		defer l.wg.Wait()
-		close(l.doneC)
+		first = true
 
+		// Prevent Add from modifying l.ls and failover from activating
+		// net.Listeners in standby from now on.
+		l.mu.Lock()
+		close(l.doneC)
 		for _, ln := range l.ls {
-			// Close all listeners to avoid any file descriptor leaks, but only
-			// propagate the first returned error to the caller.
-			if lerr := ln.Close(); lerr != nil && err == nil {
-				err = lerr
+			if ln.standby {
+				close(ln.exitC)
 			}
 		}
+		l.mu.Unlock()
+
+		// Prevent Accept from starting any accept goroutines from now on,
+		// noting that there is nothing to wait for if they never started.
+		l.acceptOnce.Do(func() {
+			for _, ln := range l.ls {
+				if !ln.standby {
+					close(ln.exitC)
+				}
+			}
+		})
+
+		// Wake any accept goroutines waiting on demand in pull mode so they
+		// can observe doneC.
+		l.pullMu.Lock()
+		l.pullCond.Broadcast()
+		l.pullMu.Unlock()
+
+		go l.teardown()
 	})
 
-	return err
+	return first
+}
+
+// teardown completes the shutdown sequence begun by close, and then signals
+// completion via l.closedC.
+func (l *Listener) teardown() {
+	// Stop accepting: close all owned net.Listeners concurrently so that a
+	// single misbehaving net.Listener cannot prevent the others from closing,
+	// and wait for their accept goroutines to exit.
+	var (
+		errs = make([]error, len(l.ls))
+		wg   sync.WaitGroup
+	)
+
+	wg.Add(len(l.ls))
+	for i, ln := range l.ls {
+		go func(i int, ln *listener) {
+			defer wg.Done()
+
+			if l.cfg.noClose {
+				errs[i] = ln.interrupt()
+				return
+			}
+
+			// Close all listeners to avoid any file descriptor leaks, except
+			// those which were already closed upon removal.
+			if !ln.removed.Load() {
+				errs[i] = ln.Close()
+			}
+			close(ln.closeC)
+			<-ln.exitC
+		}(i, ln)
+	}
+	wg.Wait()
+
+	// Drain: no accept goroutines remain to send on l.acceptC, so close any
+	// connections buffered there which will never be returned by Accept.
+	for done := false; !done; {
+		select {
+		case a := <-l.acceptC:
+			closeConn(a.c)
+		default:
+			done = true
+		}
+	}
+
+	// Release: only propagate the first returned error to the caller.
+	for _, err := range errs {
+		if err != nil {
+			l.closeErr = err
+			break
+		}
+	}
+
+	close(l.closedC)
+}
+
+// interrupt stops the accept goroutine for ln without closing ln, as
+// configured by WithoutListenerClose.
+func (ln *listener) interrupt() error {
+	// Unblock a pending Accept if possible, and otherwise wait for it to
+	// return of its own accord.
+	dl, ok := ln.Listener.(deadlineListener)
+	if ok {
+		_ = dl.SetDeadline(aLongTimeAgo)
+	}
+
+	close(ln.closeC)
+	<-ln.exitC
+
+	if !ok {
+		return nil
+	}
+
+	// Leave ln as it was found for the caller.
+	return dl.SetDeadline(time.Time{})
+}
+
+// aLongTimeAgo is a deadline in the past which causes a pending Accept to
+// return immediately.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// isClosed reports whether c has been closed.
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
 }
 
 // An accept is the result of the Accept method.
 type accept struct {
 	c   net.Conn
 	err error
+
+	// ln is the owned net.Listener which produced c or err, if any.
+	ln *listener
+
+	// at is the time at which ln's Accept method returned c, set only when
+	// WithLatencyTracking is used.
+	at time.Time
+}
+
+// run runs the accept goroutine for ln.
+func (l *Listener) run(ln *listener) {
+	defer close(ln.exitC)
+	l.accept(ln)
 }
 
 // accept begins accepting connections on ln, sending the results to l.acceptC.
-func (l *Listener) accept(ln net.Listener) {
+func (l *Listener) accept(ln *listener) {
+	// The number of consecutive errors counted against the error budget.
+	var fails int
+
 	for {
+		if l.cfg.pull && !l.wait(ln) {
+			return
+		}
+		if !l.waitResume(ln) {
+			return
+		}
+
 		c, err := ln.Accept()
 
+		var at time.Time
+		if l.cfg.latency {
+			at = time.Now()
+		}
+
 		// Prioritize the done signal over accepting a connection, but allow
 		// either to occur later to satisfy nettest.
 		select {
 		case <-l.doneC:
+			closeConn(c)
+			return
+		case <-ln.removeC:
+			closeConn(c)
 			return
 		default:
 		}
 
+		if errors.Is(err, net.ErrClosed) {
+			// This net.Listener was closed out from under the Listener and
+			// will never produce another connection.
+			l.dead(ln)
+			return
+		}
+
+		if c != nil && l.cfg.keepAlive {
+			setKeepAlive(c, l.cfg.keepAlivePeriod)
+		}
+
+		c = ln.observe(c, err, l.cfg.track)
+
+		switch {
+		case err == nil:
+			fails = 0
+		case l.cfg.budget > 0 && !isTimeout(err):
+			// Absorb the error until the budget is exhausted, and then remove
+			// the net.Listener from service.
+			fails++
+			if fails < l.cfg.budget {
+				continue
+			}
+
+			ln.removed.Store(true)
+			if !l.cfg.noClose {
+				_ = ln.Listener.Close()
+			}
+			l.dead(ln)
+			return
+		}
+
+		if err != nil {
+			err = &AcceptError{Listener: ln.Listener, Name: ln.name, Err: err}
+		}
+
+		if c != nil && ln.wrap != nil {
+			c = ln.wrap(c)
+		}
+		if c != nil && l.cfg.ctx != nil {
+			c = &contextConn{Conn: c, ctx: l.cfg.ctx(c)}
+		}
+
 		select {
 		case <-l.doneC:
+			// Nobody will receive this connection.
+			closeConn(c)
+			return
+		case <-ln.removeC:
+			closeConn(c)
 			return
-		case l.acceptC <- accept{c: c, err: err}:
+		case l.acceptC <- accept{c: c, err: err, ln: ln, at: at}:
 		}
+
+		if l.cfg.pull {
+			// acceptC is unbuffered in pull mode, so a waiting caller received
+			// this result and its demand has been satisfied.
+			l.pullMu.Lock()
+			l.waiting--
+			l.pullMu.Unlock()
+		}
+	}
+}
+
+// closeConn closes c if it is not nil.
+func closeConn(c net.Conn) {
+	if c != nil {
+		_ = c.Close()
+	}
+}
+
+// dead records that ln has failed permanently or was removed, at most once.
+// If it was the last one in its tier, the next tier is activated, and if it
+// was the last one overall, any callers blocked in Accept are woken.
+func (l *Listener) dead(ln *listener) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if ln.failed {
+		return
+	}
+	ln.failed = true
+
+	l.tierLive[ln.tier]--
+	l.failover()
+
+	if l.live.Add(-1) == 0 {
+		close(l.deadC)
+	}
+}
+
+// failover activates the next tier with live net.Listeners once the current
+// tier has none. The caller must hold l.mu.
+func (l *Listener) failover() {
+	if isClosed(l.doneC) {
+		return
+	}
+
+	for l.tierLive[l.tier] == 0 && l.tier < len(l.tierLive)-1 {
+		l.tier++
+		for _, ln := range l.ls {
+			if ln.tier != l.tier {
+				continue
+			}
+
+			ln.standby = false
+			if l.started {
+				go l.run(ln)
+			}
+		}
+	}
+}
+
+// listeners returns a snapshot of the net.Listeners owned by l. As l.ls is
+// never modified in place, the returned slice is safe to use without holding
+// l.mu.
+func (l *Listener) listeners() []*listener {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.ls
+}
+
+// isTimeout reports whether err is a timeout error.
+func isTimeout(err error) bool {
+	var ne interface{ Timeout() bool }
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// wait blocks until a caller is waiting in Accept, reporting false if the
+// Listener was closed or ln was removed in the meantime.
+func (l *Listener) wait(ln *listener) bool {
+	l.pullMu.Lock()
+	defer l.pullMu.Unlock()
+
+	for {
+		select {
+		case <-l.doneC:
+			return false
+		case <-ln.removeC:
+			return false
+		default:
+		}
+
+		if l.waiting > 0 {
+			return true
+		}
+
+		l.pullCond.Wait()
 	}
 }
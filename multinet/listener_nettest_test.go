@@ -9,13 +9,34 @@ import (
 )
 
 func TestIntegrationNettestTestListener(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []multinet.Option
+	}{
+		{name: "push"},
+		{
+			name: "pull",
+			opts: []multinet.Option{multinet.WithPullMode()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nettestx.TestListener(t, makeOpenerSet(tt.opts...))
+		})
+	}
+}
+
+func TestIntegrationNettestTestListenerDeadlineShim(t *testing.T) {
 	mos := func() (ln net.Listener, dial func(net.Addr) (net.Conn, error), stop func(), err error) {
 		l4, err := net.Listen("tcp", ":0")
 		if err != nil {
 			return nil, nil, nil, err
 		}
 
-		l := multinet.Listen(l4)
+		// Hide the SetDeadline method of the TCP listener so that only the
+		// shim can provide deadline support.
+		l := multinet.Listen(multinet.WithDeadlineShim(plainListener{l4}))
 
 		stop = func() {
 			_ = l.Close()
@@ -30,3 +51,27 @@ func TestIntegrationNettestTestListener(t *testing.T) {
 
 	nettestx.TestListener(t, mos)
 }
+
+// A plainListener exposes only the methods of net.Listener.
+type plainListener struct{ net.Listener }
+
+func makeOpenerSet(opts ...multinet.Option) nettestx.MakeOpenerSet {
+	return func() (ln net.Listener, dial func(net.Addr) (net.Conn, error), stop func(), err error) {
+		l4, err := net.Listen("tcp", ":0")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		l := multinet.NewListener([]net.Listener{l4}, opts...)
+
+		stop = func() {
+			_ = l.Close()
+		}
+
+		dial = func(addr net.Addr) (net.Conn, error) {
+			return net.Dial(addr.Network(), addr.String())
+		}
+
+		return l, dial, stop, nil
+	}
+}
@@ -8,7 +8,12 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -80,6 +85,229 @@ func TestListenerAddr(t *testing.T) {
 	}
 }
 
+func TestListenerPrimary(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.NewListener(
+			[]net.Listener{tcp1, tcp2},
+			multinet.WithPrimary(tcp2),
+		)
+	)
+	defer l.Close()
+
+	if diff := cmp.Diff(tcp2.Addr(), l.Addr()); diff != "" {
+		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	}
+
+	want := multinet.Addr{tcp1.Addr(), tcp2.Addr()}
+	if diff := cmp.Diff(want, l.Addrs()); diff != "" {
+		t.Fatalf("unexpected Addrs (-want +got):\n%s", diff)
+	}
+
+	// Connections are still accepted from the non-primary listener.
+	acceptOne(t, l, tcp1.Addr())
+}
+
+func TestListenerFindListener(t *testing.T) {
+	var (
+		unix = localListener("unix")
+		tcp  = localListener("tcp")
+		l    = multinet.Listen(unix, multinet.Named("tcp", tcp))
+	)
+	defer l.Close()
+
+	ln, ok := l.FindListener(func(ln net.Listener) bool {
+		_, ok := ln.(*net.TCPListener)
+		return ok
+	})
+	if !ok {
+		t.Fatal("failed to find *net.TCPListener")
+	}
+
+	if diff := cmp.Diff(tcp.Addr().String(), ln.Addr().String()); diff != "" {
+		t.Fatalf("unexpected listener address (-want +got):\n%s", diff)
+	}
+
+	if _, ok := l.FindListener(func(net.Listener) bool { return false }); ok {
+		t.Fatal("found listener which does not match")
+	}
+}
+
+func TestListenerListenerFor(t *testing.T) {
+	// Fake listeners avoid binding to wildcard addresses on the test host.
+	var (
+		tcp4 = &addrListener{addr: &net.TCPAddr{IP: net.IPv4zero, Port: 80}}
+		tcp6 = &addrListener{addr: &net.TCPAddr{IP: net.IPv6unspecified, Port: 443}}
+		lo   = &addrListener{addr: &net.TCPAddr{IP: net.IPv6loopback, Port: 80}}
+		unix = &addrListener{addr: &net.UnixAddr{Net: "unix", Name: "/tmp/foo.sock"}}
+		l    = multinet.Listen(tcp4, tcp6, lo, unix)
+	)
+
+	tests := []struct {
+		name  string
+		local net.Addr
+		ln    net.Listener
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:  "nil TCP",
+			local: (*net.TCPAddr)(nil),
+		},
+		{
+			name:  "IPv4 wildcard",
+			local: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 80},
+			ln:    tcp4,
+		},
+		{
+			name:  "IPv6 exact preferred over wildcard",
+			local: &net.TCPAddr{IP: net.IPv6loopback, Port: 80},
+			ln:    lo,
+		},
+		{
+			name:  "IPv6 not matched by IPv4 wildcard",
+			local: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 80},
+		},
+		{
+			name:  "IPv6 wildcard",
+			local: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 443},
+			ln:    tcp6,
+		},
+		{
+			name:  "IPv6 wildcard matches IPv4",
+			local: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 443},
+			ln:    tcp6,
+		},
+		{
+			name:  "wrong network",
+			local: &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 80},
+		},
+		{
+			name:  "wrong port",
+			local: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 8080},
+		},
+		{
+			name:  "UNIX",
+			local: &net.UnixAddr{Net: "unix", Name: "/tmp/foo.sock"},
+			ln:    unix,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, ok := l.ListenerFor(tt.local)
+			if diff := cmp.Diff(tt.ln != nil, ok); diff != "" {
+				t.Fatalf("unexpected ok (-want +got):\n%s", diff)
+			}
+
+			if ln != tt.ln {
+				t.Fatalf("unexpected listener: %v", ln)
+			}
+		})
+	}
+}
+
+func TestAddrDedup(t *testing.T) {
+	var (
+		tcp  = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+		udp  = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+		unix = &net.UnixAddr{Net: "unix", Name: "/tmp/foo"}
+	)
+
+	addr := multinet.Addr{
+		tcp,
+		udp,
+		&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80},
+		unix,
+		tcp,
+	}
+
+	if diff := cmp.Diff(multinet.Addr{tcp, udp, unix}, addr.Dedup()); diff != "" {
+		t.Fatalf("unexpected deduplicated addresses (-want +got):\n%s", diff)
+	}
+
+	// Sharded net.Listeners on the same address are only reported once when
+	// configured.
+	var (
+		ln1 = &addrListener{addr: tcp}
+		ln2 = &addrListener{addr: tcp}
+		ln3 = &addrListener{addr: unix}
+	)
+
+	tests := []struct {
+		name string
+		opts []multinet.Option
+		s    string
+	}{
+		{
+			name: "default",
+			s:    "127.0.0.1:80,127.0.0.1:80,/tmp/foo",
+		},
+		{
+			name: "dedup",
+			opts: []multinet.Option{multinet.WithDedupAddr()},
+			s:    "127.0.0.1:80,/tmp/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := multinet.NewListener([]net.Listener{ln1, ln2, ln3}, tt.opts...)
+			if diff := cmp.Diff(tt.s, l.Addr().String()); diff != "" {
+				t.Fatalf("unexpected listener address (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(3, len(l.Addrs())); diff != "" {
+				t.Fatalf("unexpected number of addresses (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddrFilter(t *testing.T) {
+	var (
+		tcp4 = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+		tcp6 = &net.TCPAddr{IP: net.IPv6loopback, Port: 80}
+		tcpW = &net.TCPAddr{Port: 8080}
+		udp4 = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+		unix = &net.UnixAddr{Net: "unix", Name: "/tmp/foo"}
+
+		addr = multinet.Addr{tcp4, tcp6, tcpW, udp4, unix}
+	)
+
+	tests := []struct {
+		network string
+		want    multinet.Addr
+	}{
+		{network: "tcp", want: multinet.Addr{tcp4, tcp6, tcpW}},
+		{network: "tcp4", want: multinet.Addr{tcp4, tcpW}},
+		{network: "tcp6", want: multinet.Addr{tcp6, tcpW}},
+		{network: "udp4", want: multinet.Addr{udp4}},
+		{network: "udp6"},
+		{network: "unix", want: multinet.Addr{unix}},
+		{network: "unixgram"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, addr.Filter(tt.network)); diff != "" {
+				t.Fatalf("unexpected filtered addresses (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	want := map[string]multinet.Addr{
+		"tcp":  {tcp4, tcp6, tcpW},
+		"udp":  {udp4},
+		"unix": {unix},
+	}
+
+	if diff := cmp.Diff(want, addr.ByNetwork()); diff != "" {
+		t.Fatalf("unexpected grouped addresses (-want +got):\n%s", diff)
+	}
+}
+
 func TestListenerHTTP(t *testing.T) {
 	// Open several local listeners using different socket types so that we can
 	// verify each works as expected for HTTP requests.
@@ -200,139 +428,1708 @@ func TestListenerCloseError(t *testing.T) {
 	}
 }
 
-func TestListenerNoSetDeadline(t *testing.T) {
-	// TCP listener supports deadlines, but errListener does not.
-	l := multinet.Listen(localListener("tcp"), &errListener{})
-	defer l.Close()
+func TestListenerCloseTimeout(t *testing.T) {
+	// A net.Listener whose Accept blocks forever, even after Close.
+	hl := newHangListener()
+	defer hl.unblock()
 
-	if err := l.SetDeadline(time.Now()); err == nil {
+	var (
+		tcp = localListener("tcp")
+		l   = multinet.Listen(tcp, hl)
+	)
+
+	// Start the accept goroutines and wait for both to begin accepting.
+	go func() { _, _ = l.Accept() }()
+	<-hl.acceptingC
+
+	err := l.CloseTimeout(100 * time.Millisecond)
+	if err == nil {
 		t.Fatal("expected an error, but none occurred")
 	}
+
+	// Only the misbehaving listener should be reported.
+	if !strings.Contains(err.Error(), hl.Addr().String()) {
+		t.Fatalf("error does not identify hung listener: %v", err)
+	}
+	if strings.Contains(err.Error(), tcp.Addr().String()) {
+		t.Fatalf("error identifies well-behaved listener: %v", err)
+	}
 }
 
-func TestListenNoListeners(t *testing.T) {
-	// While a Listener constructed with no net.Listeners wouldn't be useful,
-	// we should verify it doesn't panic or similar.
-	l := multinet.Listen()
+func TestListenerShutdownOrder(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(tcp)
 
-	if diff := cmp.Diff(multinet.Addr{}, l.Addr()); diff != "" {
-		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	// Accept one connection so the accept goroutine starts, and then leave a
+	// second connection buffered without a caller to receive it.
+	acceptOne(t, l, tcp.Addr())
+
+	c, err := net.Dial("tcp", tcp.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
 	}
+	defer c.Close()
 
-	// Close before and after accept to verify sanity.
-	doClose := func() {
-		for i := 0; i < 3; i++ {
-			if err := l.Close(); err != nil {
-				t.Fatalf("failed to close listener: %v", err)
-			}
-		}
+	waitStats(t, l, func(ss []multinet.ListenerStats) bool {
+		return ss[0].Accepted == 2
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := l.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
 	}
 
-	doClose()
+	// The buffered connection was drained and closed even without
+	// WithConnTracking.
+	if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) && !errors.Is(err, syscall.ECONNRESET) {
+		t.Fatalf("unexpected read error: %v", err)
+	}
 
-	if c, err := l.Accept(); err == nil || c != nil {
-		t.Fatalf("expected nil net.Conn (got: %#v) and non-nil error", c)
+	// No connection is accepted once Shutdown begins: Accept fails, and the
+	// net.Listener no longer accepts connections from the operating system.
+	if _, err := l.Accept(); err == nil {
+		t.Fatal("expected an error after Shutdown, but none occurred")
+	}
+	if c, err := net.Dial("tcp", tcp.Addr().String()); err == nil {
+		_ = c.Close()
+		t.Fatal("dialed a net.Listener which should be closed")
 	}
 
-	doClose()
+	if diff := cmp.Diff(uint64(2), l.Stats()[0].Accepted); diff != "" {
+		t.Fatalf("unexpected accepted count (-want +got):\n%s", diff)
+	}
 }
 
-func compareErrors(x, y error) bool {
-	switch {
-	case x == nil && y == nil:
-		// Both nil.
-		return true
-	case x == nil || y == nil:
-		// One or the other nil.
-		return false
-	default:
-		// Verify by string contents.
-		return x.Error() == y.Error()
+func TestListenerDone(t *testing.T) {
+	// A net.Listener whose Accept blocks until unblocked, even after Close.
+	hl := newHangListener()
+
+	l := multinet.Listen(localListener("tcp"), hl)
+
+	go func() { _, _ = l.Accept() }()
+	<-hl.acceptingC
+
+	if err := l.CloseTimeout(50 * time.Millisecond); err == nil {
+		t.Fatal("expected an error, but none occurred")
 	}
-}
 
-func localListener(network string) net.Listener {
-	l, err := nettest.NewLocalListener(network)
-	if err != nil {
-		panicf("failed to create local listener: %v", err)
+	// Teardown outlives CloseTimeout until the hung listener returns.
+	select {
+	case <-l.Done():
+		t.Fatal("Done closed before teardown completed")
+	default:
 	}
 
-	return l
-}
+	hl.unblock()
 
-func httpGet(t *testing.T, addr net.Addr) string {
-	t.Helper()
+	select {
+	case <-l.Done():
+	case <-time.After(1 * time.Second):
+		t.Fatal("timed out waiting for Done")
+	}
+}
 
+func TestListenerWithoutListenerClose(t *testing.T) {
 	var (
-		transport = &http.Transport{}
-		reqAddr   string
+		tcp   = localListener("tcp")
+		plain = plainListener{localListener("tcp")}
 	)
+	defer tcp.Close()
+	defer plain.Close()
 
-	switch addr.(type) {
-	case *net.TCPAddr:
-		// Send requests to the TCP address of the server.
-		reqAddr = (&url.URL{
-			Scheme: "http",
-			Host:   addr.String(),
-		}).String()
-	case *net.UnixAddr:
-		// Send requests over UNIX socket instead of TCP.
-		transport.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
-			return net.Dial("unix", addr.String())
-		}
+	l := multinet.NewListener(
+		[]net.Listener{tcp, plain},
+		multinet.WithoutListenerClose(),
+	)
 
-		// It seems this just has to be set to something nonsensical since we
-		// are overriding Dial with a known address anyway.
-		reqAddr = "http://foo"
-	default:
-		panicf("unhandled type: %T", addr)
-	}
+	// Start the accept goroutines.
+	acceptOne(t, l, tcp.Addr())
 
-	c := &http.Client{
-		Timeout:   1 * time.Second,
-		Transport: transport,
+	// The pending Accept on the net.Listener with deadline support is
+	// interrupted, but the other delays Close until it accepts a connection.
+	errC := make(chan error, 1)
+	go func() { errC <- l.Close() }()
+
+	select {
+	case err := <-errC:
+		t.Fatalf("Close returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
 	}
 
-	req, err := http.NewRequest(http.MethodGet, reqAddr, nil)
+	c, err := net.Dial("tcp", plain.Addr().String())
 	if err != nil {
-		t.Fatalf("failed to create HTTP request: %v", err)
+		t.Fatalf("failed to dial: %v", err)
 	}
+	defer c.Close()
 
-	res, err := c.Do(req)
-	if err != nil {
-		t.Fatalf("failed to perform HTTP request: %v", err)
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to close listener: %v", err)
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		t.Fatalf("expected HTTP 200, but got HTTP %d", res.StatusCode)
+	// The connection accepted while closing is closed rather than returned.
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected read error: %v", err)
 	}
 
-	b, err := io.ReadAll(io.LimitReader(res.Body, 1024))
-	if err != nil {
-		t.Fatalf("failed to read HTTP body: %v", err)
+	// Both net.Listeners remain usable with no deadline set.
+	for _, ln := range []net.Listener{tcp, plain} {
+		acceptOne(t, ln, ln.Addr())
 	}
+}
 
-	return string(b)
+func TestListenerCloseTimeoutOK(t *testing.T) {
+	l := multinet.Listen(localListener("tcp"), localListener("tcp"))
+
+	go func() { _, _ = l.Accept() }()
+
+	if err := l.CloseTimeout(1 * time.Second); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
 }
 
-func parseAddrIP(s string) net.IP {
-	// Assume s is a host:port string where host is always an IP.
-	host, _, err := net.SplitHostPort(s)
-	if err != nil {
-		panicf("failed to split host/port: %v", err)
+func TestListenerLen(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(tcp1, tcp2)
+	)
+	defer l.Close()
+
+	if diff := cmp.Diff(2, l.Len()); diff != "" {
+		t.Fatalf("unexpected initial Len (-want +got):\n%s", diff)
 	}
 
-	ip := net.ParseIP(host)
-	if ip == nil {
-		panicf("failed to parse IP address: %v", err)
+	// Start the accept goroutines, then close one listener out from under
+	// the Listener, which should permanently remove it from service.
+	acceptOne(t, l, tcp2.Addr())
+	_ = tcp1.Close()
+
+	waitLen(t, l, 1)
+
+	// The remaining listener still accepts connections.
+	acceptOne(t, l, tcp2.Addr())
+
+	if err := l.Close(); err == nil {
+		t.Fatal("expected an error closing an already closed listener")
 	}
 
-	return ip
+	if diff := cmp.Diff(0, l.Len()); diff != "" {
+		t.Fatalf("unexpected Len after Close (-want +got):\n%s", diff)
+	}
 }
 
-func panicf(format string, a ...interface{}) {
-	panic(fmt.Sprintf(format, a...))
+func TestListenerAllListenersClosed(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(tcp1, tcp2)
+	)
+	defer l.Close()
+
+	// Start the accept goroutines, then close every listener out from under
+	// the Listener so that all of them fail permanently.
+	acceptOne(t, l, tcp1.Addr())
+	_ = tcp1.Close()
+	_ = tcp2.Close()
+
+	errC := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		errC <- err
+	}()
+
+	select {
+	case err := <-errC:
+		if !errors.Is(err, multinet.ErrAllListenersClosed) {
+			t.Fatalf("expected all listeners closed error, but got: %v", err)
+		}
+		if !errors.Is(err, net.ErrClosed) {
+			t.Fatalf("expected error to wrap net.ErrClosed, but got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Accept to return")
+	}
+
+	if diff := cmp.Diff(0, l.Len()); diff != "" {
+		t.Fatalf("unexpected Len (-want +got):\n%s", diff)
+	}
+}
+
+func TestListenContext(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		tcp         = localListener("tcp")
+		l           = multinet.ListenContext(ctx, tcp)
+	)
+	defer cancel()
+
+	acceptOne(t, l, tcp.Addr())
+
+	errC := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		errC <- err
+	}()
+
+	// Canceling the context unblocks Accept and closes the net.Listener.
+	cancel()
+
+	select {
+	case err := <-errC:
+		if err == nil {
+			t.Fatal("expected an error, but none occurred")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Accept to return")
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+
+	if _, err := tcp.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.Listener to be closed, but got: %v", err)
+	}
+
+	// The Listener remains available for inspection.
+	if diff := cmp.Diff(multinet.Addr{tcp.Addr()}, l.Addr()); diff != "" {
+		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(0, l.Len()); diff != "" {
+		t.Fatalf("unexpected Len (-want +got):\n%s", diff)
+	}
+}
+
+func TestListenerAcceptError(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(tcp1, tcp2)
+	)
+	defer l.Close()
+
+	// Only the second listener times out.
+	if err := tcp2.(*net.TCPListener).SetDeadline(time.Now()); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	_, err := l.Accept()
+
+	var aerr *multinet.AcceptError
+	if !errors.As(err, &aerr) {
+		t.Fatalf("expected *multinet.AcceptError, but got: %#v", err)
+	}
+	if aerr.Listener != tcp2 {
+		t.Fatalf("unexpected listener in error: %v", aerr.Listener.Addr())
+	}
+
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, but got: %v", err)
+	}
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("expected timeout error, but got: %v", err)
+	}
+}
+
+func TestListenerErrorBudget(t *testing.T) {
+	const budget = 3
+	errFoo := errors.New("foo")
+
+	tests := []struct {
+		name    string
+		script  []error
+		removed bool
+		calls   int32
+	}{
+		{
+			// The budget is exhausted before the final error.
+			name:    "removed",
+			script:  []error{errFoo, errFoo, errFoo, errFoo},
+			removed: true,
+			calls:   budget,
+		},
+		{
+			// A successful accept resets the budget.
+			name:   "reset",
+			script: []error{errFoo, errFoo, nil, errFoo, errFoo},
+			calls:  5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tcp = localListener("tcp")
+				sl  = newScriptListener(tt.script)
+				l   = multinet.NewListener(
+					[]net.Listener{tcp, sl},
+					multinet.WithErrorBudget(budget),
+				)
+			)
+			defer l.Close()
+
+			if tt.removed {
+				// The scripted listener only produces errors, none of which
+				// are forwarded.
+				acceptOne(t, l, tcp.Addr())
+				waitLen(t, l, 1)
+			} else {
+				// Receive the scripted connection, and wait for the scripted
+				// listener to run out of results.
+				c, err := l.Accept()
+				if err != nil {
+					t.Fatalf("failed to accept: %v", err)
+				}
+				_ = c.Close()
+
+				<-sl.doneC
+			}
+
+			if diff := cmp.Diff(tt.calls, sl.calls.Load()); diff != "" {
+				t.Fatalf("unexpected number of Accept calls (-want +got):\n%s", diff)
+			}
+
+			s := l.Stats()[1]
+			if diff := cmp.Diff(tt.removed, s.Removed); diff != "" {
+				t.Fatalf("unexpected removed state (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.removed, sl.closed.Load()); diff != "" {
+				t.Fatalf("unexpected closed state (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListenerCloseAcceptRace(t *testing.T) {
+	iterations := 1000
+	if testing.Short() {
+		iterations = 100
+	}
+
+	for i := 0; i < iterations; i++ {
+		var (
+			tcp1 = localListener("tcp")
+			tcp2 = localListener("tcp")
+			l    = multinet.Listen(tcp1, tcp2)
+			wg   sync.WaitGroup
+		)
+
+		// Many concurrent callers accept until the Listener is closed, while
+		// connections arrive on both net.Listeners.
+		const callers = 8
+		wg.Add(callers + 2)
+		for j := 0; j < callers; j++ {
+			go func() {
+				defer wg.Done()
+				for {
+					c, err := l.Accept()
+					if err != nil {
+						return
+					}
+					_ = c.Close()
+				}
+			}()
+		}
+
+		for _, addr := range []net.Addr{tcp1.Addr(), tcp2.Addr()} {
+			go func(addr net.Addr) {
+				defer wg.Done()
+				c, err := net.Dial(addr.Network(), addr.String())
+				if err == nil {
+					_ = c.Close()
+				}
+			}(addr)
+		}
+
+		// Close concurrently with itself and with Accept.
+		errC := make(chan error, 2)
+		for j := 0; j < 2; j++ {
+			go func() { errC <- l.Close() }()
+		}
+		for j := 0; j < 2; j++ {
+			if err := <-errC; err != nil {
+				t.Fatalf("failed to close listener: %v", err)
+			}
+		}
+
+		wg.Wait()
+
+		// All callers have observed the close.
+		if _, err := l.Accept(); err == nil {
+			t.Fatal("expected an error after Close, but none occurred")
+		}
+	}
+}
+
+func TestListenerNamed(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(tcp1, multinet.Named("admin", tcp2))
+	)
+	defer l.Close()
+
+	// The named listener is unwrapped, so it still supports deadlines. Only
+	// the named listener times out.
+	if err := l.SetDeadline(time.Now()); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+	if err := tcp1.(*net.TCPListener).SetDeadline(time.Time{}); err != nil {
+		t.Fatalf("failed to clear deadline: %v", err)
+	}
+
+	_, err := l.Accept()
+
+	var aerr *multinet.AcceptError
+	if !errors.As(err, &aerr) {
+		t.Fatalf("expected *multinet.AcceptError, but got: %#v", err)
+	}
+	if aerr.Listener != tcp2 {
+		t.Fatalf("unexpected listener in error: %v", aerr.Listener.Addr())
+	}
+	if diff := cmp.Diff("admin", aerr.Name); diff != "" {
+		t.Fatalf("unexpected name in error (-want +got):\n%s", diff)
+	}
+	if !strings.HasPrefix(err.Error(), "admin: ") {
+		t.Fatalf("error message does not include name: %v", err)
+	}
+
+	var names []string
+	for _, s := range l.Stats() {
+		names = append(names, s.Name)
+	}
+
+	if diff := cmp.Diff([]string{"", "admin"}, names); diff != "" {
+		t.Fatalf("unexpected names (-want +got):\n%s", diff)
+	}
+}
+
+func TestListenerSetDeadlineFor(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(
+			multinet.Named("public", tcp1),
+			multinet.Named("admin", tcp2),
+			multinet.Named("plain", plainListener{localListener("tcp")}),
+		)
+	)
+	defer l.Close()
+
+	if err := l.SetDeadlineFor("unknown", time.Now()); err == nil {
+		t.Fatal("expected an error for unknown name, but none occurred")
+	}
+	if err := l.SetDeadlineFor("plain", time.Now()); err == nil {
+		t.Fatal("expected an error for no SetDeadline, but none occurred")
+	}
+
+	// Only the admin listener times out.
+	if err := l.SetDeadlineFor("admin", time.Now()); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	_, err := l.Accept()
+
+	var aerr *multinet.AcceptError
+	if !errors.As(err, &aerr) || !aerr.Timeout() {
+		t.Fatalf("expected timeout *multinet.AcceptError, but got: %v", err)
+	}
+	if diff := cmp.Diff("admin", aerr.Name); diff != "" {
+		t.Fatalf("unexpected name in error (-want +got):\n%s", diff)
+	}
+
+	// The public listener still accepts connections.
+	if err := l.SetDeadlineFor("admin", time.Time{}); err != nil {
+		t.Fatalf("failed to clear deadline: %v", err)
+	}
+
+	c, err := net.Dial("tcp", tcp1.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	for {
+		ac, err := l.Accept()
+		if err != nil {
+			// Drain any timeout errors buffered from the admin listener.
+			continue
+		}
+		_ = ac.Close()
+		break
+	}
+}
+
+func TestListenerNoSetDeadline(t *testing.T) {
+	// TCP listener supports deadlines, but errListener does not.
+	l := multinet.Listen(localListener("tcp"), &errListener{})
+	defer l.Close()
+
+	if err := l.SetDeadline(time.Now()); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestListenerSetDeadlineBestEffort(t *testing.T) {
+	var (
+		tcp    = localListener("tcp")
+		closed = localListener("tcp")
+	)
+	_ = closed.Close()
+
+	// plainListener does not support deadlines and is skipped, while the
+	// closed TCP listener reports an error.
+	l := multinet.Listen(tcp, plainListener{localListener("tcp")}, closed)
+	defer l.Close()
+
+	errs := l.SetDeadlineBestEffort(time.Now().Add(50 * time.Millisecond))
+	if diff := cmp.Diff(3, len(errs)); diff != "" {
+		t.Fatalf("unexpected number of errors (-want +got):\n%s", diff)
+	}
+
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("unexpected errors for first two listeners: %v", errs[:2])
+	}
+	if !errors.Is(errs[2], net.ErrClosed) {
+		t.Fatalf("expected closed error for third listener, but got: %v", errs[2])
+	}
+
+	// The deadline still applies to the listener which accepted it.
+	_, err := l.Accept()
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("expected timeout error, but got: %v", err)
+	}
+}
+
+func TestListenerDeadlineShim(t *testing.T) {
+	// The shim allows a mix of listeners with and without deadline support.
+	var (
+		tcp  = localListener("tcp")
+		shim = multinet.WithDeadlineShim(plainListener{localListener("tcp")})
+		l    = multinet.Listen(tcp, shim)
+	)
+	defer l.Close()
+
+	if err := l.SetDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	_, err := l.Accept()
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("expected timeout error, but got: %v", err)
+	}
+
+	// Clear the deadline and verify the shimmed listener still accepts
+	// connections after timing out.
+	if err := l.SetDeadline(time.Time{}); err != nil {
+		t.Fatalf("failed to clear deadline: %v", err)
+	}
+
+	c, err := net.Dial("tcp", shim.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	for {
+		// Drain any timeout errors still buffered from the first deadline.
+		ac, err := l.Accept()
+		if err != nil {
+			continue
+		}
+		defer ac.Close()
+
+		if diff := cmp.Diff(c.LocalAddr().String(), ac.RemoteAddr().String()); diff != "" {
+			t.Fatalf("unexpected remote address (-want +got):\n%s", diff)
+		}
+
+		break
+	}
+}
+
+func TestListenerConnWrapper(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+	)
+
+	// Only connections from tcp1 are wrapped, twice, in order.
+	wrap := func(tag string) func(net.Conn) net.Conn {
+		return func(c net.Conn) net.Conn {
+			if tc, ok := c.(*taggedConn); ok {
+				tc.tags = append(tc.tags, tag)
+				return tc
+			}
+
+			return &taggedConn{Conn: c, tags: []string{tag}}
+		}
+	}
+
+	l := multinet.NewListener(
+		[]net.Listener{tcp1, tcp2},
+		multinet.WithConnWrapper(tcp1, wrap("a")),
+		multinet.WithConnWrapper(tcp1, wrap("b")),
+	)
+	defer l.Close()
+
+	accept := func(addr net.Addr) net.Conn {
+		c, err := net.Dial(addr.Network(), addr.String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+
+		ac, err := l.Accept()
+		if err != nil {
+			t.Fatalf("failed to accept: %v", err)
+		}
+		_ = ac.Close()
+
+		return ac
+	}
+
+	tc, ok := accept(tcp1.Addr()).(*taggedConn)
+	if !ok {
+		t.Fatal("connection from first listener was not wrapped")
+	}
+
+	if diff := cmp.Diff([]string{"a", "b"}, tc.tags); diff != "" {
+		t.Fatalf("unexpected wrapper tags (-want +got):\n%s", diff)
+	}
+
+	if _, ok := accept(tcp2.Addr()).(*taggedConn); ok {
+		t.Fatal("connection from second listener was wrapped")
+	}
+}
+
+func TestListenerShutdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []multinet.Option
+		close  bool
+		err    error
+		closed bool
+	}{
+		{
+			name: "untracked",
+		},
+		{
+			name:   "tracked closed",
+			opts:   []multinet.Option{multinet.WithConnTracking()},
+			close:  true,
+			closed: true,
+		},
+		{
+			name:   "tracked grace period exceeded",
+			opts:   []multinet.Option{multinet.WithConnTracking()},
+			err:    context.DeadlineExceeded,
+			closed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tcp = localListener("tcp")
+				l   = multinet.NewListener([]net.Listener{tcp}, tt.opts...)
+			)
+
+			c, err := net.Dial("tcp", tcp.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+
+			ac, err := l.Accept()
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+			defer ac.Close()
+
+			if tt.close {
+				// Close the connection partway through the grace period.
+				timer := time.AfterFunc(50*time.Millisecond, func() { _ = ac.Close() })
+				defer timer.Stop()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+			defer cancel()
+
+			if err := l.Shutdown(ctx); !errors.Is(err, tt.err) {
+				t.Fatalf("unexpected shutdown error: %v", err)
+			}
+
+			// The peer observes EOF once the accepted connection is closed.
+			if err := c.SetReadDeadline(time.Now().Add(250 * time.Millisecond)); err != nil {
+				t.Fatalf("failed to set read deadline: %v", err)
+			}
+
+			_, err = c.Read(make([]byte, 1))
+			if diff := cmp.Diff(tt.closed, errors.Is(err, io.EOF)); diff != "" {
+				t.Fatalf("unexpected connection closed state (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListenerShutdownBuffered(t *testing.T) {
+	var (
+		tcp = localListener("tcp")
+		l   = multinet.NewListener([]net.Listener{tcp}, multinet.WithConnTracking())
+	)
+
+	// Accept one connection so the accept goroutine starts, and then leave a
+	// second connection buffered without a caller to receive it.
+	acceptOne(t, l, tcp.Addr())
+
+	c, err := net.Dial("tcp", tcp.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	waitStats(t, l, func(ss []multinet.ListenerStats) bool {
+		return ss[0].Active == 1
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := l.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
+	}
+}
+
+func TestListenPrioritized(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.ListenPrioritized([][]net.Listener{{tcp1}, {tcp2}})
+	)
+	defer l.Close()
+
+	accept := func() net.Addr {
+		t.Helper()
+
+		c, err := l.Accept()
+		if err != nil {
+			t.Fatalf("failed to accept: %v", err)
+		}
+		_ = c.Close()
+
+		return c.LocalAddr()
+	}
+
+	// Although a connection to the standby listener is pending first, only
+	// the active listener is accepted from.
+	for _, addr := range []net.Addr{tcp2.Addr(), tcp1.Addr()} {
+		c, err := net.Dial(addr.Network(), addr.String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+	}
+
+	if diff := cmp.Diff(tcp1.Addr().String(), accept().String()); diff != "" {
+		t.Fatalf("unexpected active listener address (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(2, l.Len()); diff != "" {
+		t.Fatalf("unexpected number of listeners (-want +got):\n%s", diff)
+	}
+
+	// Once the active listener fails, the standby listener takes over and
+	// accepts the pending connection.
+	_ = tcp1.Close()
+	waitLen(t, l, 1)
+
+	if diff := cmp.Diff(tcp2.Addr().String(), accept().String()); diff != "" {
+		t.Fatalf("unexpected standby listener address (-want +got):\n%s", diff)
+	}
+
+	_ = tcp2.Close()
+	if _, err := l.Accept(); !errors.Is(err, multinet.ErrAllListenersClosed) {
+		t.Fatalf("unexpected accept error: %v", err)
+	}
+}
+
+func TestListenPrioritizedEmptyTier(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.ListenPrioritized([][]net.Listener{nil, {tcp}, nil})
+	defer l.Close()
+
+	acceptOne(t, l, tcp.Addr())
+}
+
+func TestListenPrioritizedClose(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept bool
+	}{
+		{name: "before Accept"},
+		{name: "after Accept", accept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tcp1 = localListener("tcp")
+				tcp2 = localListener("tcp")
+				l    = multinet.ListenPrioritized([][]net.Listener{{tcp1}, {tcp2}})
+			)
+
+			if tt.accept {
+				acceptOne(t, l, tcp1.Addr())
+			}
+
+			// Standby listeners are closed along with the active ones.
+			if err := l.CloseTimeout(1 * time.Second); err != nil {
+				t.Fatalf("failed to close listener: %v", err)
+			}
+
+			if _, err := tcp2.Accept(); !errors.Is(err, net.ErrClosed) {
+				t.Fatalf("unexpected standby accept error: %v", err)
+			}
+		})
+	}
+}
+
+func TestListenerDuplicate(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(tcp, multinet.Named("dup", tcp), tcp)
+
+	if diff := cmp.Diff(1, l.Len()); diff != "" {
+		t.Fatalf("unexpected number of listeners (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(1, len(l.Addrs())); diff != "" {
+		t.Fatalf("unexpected number of addresses (-want +got):\n%s", diff)
+	}
+
+	if err := l.Add(tcp); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	acceptOne(t, l, tcp.Addr())
+
+	// The net.Listener is only closed once, so no error is returned.
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+}
+
+func TestListenerAcceptFrom(t *testing.T) {
+	var (
+		tcp  = localListener("tcp")
+		unix = localListener("unix")
+		l    = multinet.Listen(tcp, unix)
+	)
+
+	for _, addr := range []net.Addr{tcp.Addr(), unix.Addr()} {
+		t.Run(addr.Network(), func(t *testing.T) {
+			c, err := net.Dial(addr.Network(), addr.String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+
+			ac, network, err := l.AcceptFrom()
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+			_ = ac.Close()
+
+			if diff := cmp.Diff(addr.Network(), network); diff != "" {
+				t.Fatalf("unexpected network (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+
+	if _, network, err := l.AcceptFrom(); err == nil || network != "" {
+		t.Fatalf("unexpected network %q and error %v after close", network, err)
+	}
+}
+
+func TestListenerAdd(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(tcp1)
+	)
+	defer l.Close()
+
+	// Start the accept goroutines and begin accepting again before the second
+	// listener is added.
+	acceptOne(t, l, tcp1.Addr())
+
+	type result struct {
+		c   net.Conn
+		err error
+	}
+	resC := make(chan result, 1)
+	go func() {
+		c, err := l.Accept()
+		resC <- result{c: c, err: err}
+	}()
+
+	if err := l.Add(tcp2); err != nil {
+		t.Fatalf("failed to add listener: %v", err)
+	}
+
+	c, err := net.Dial("tcp", tcp2.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	select {
+	case res := <-resC:
+		if res.err != nil {
+			t.Fatalf("failed to accept: %v", res.err)
+		}
+		defer res.c.Close()
+
+		if diff := cmp.Diff(c.LocalAddr().String(), res.c.RemoteAddr().String()); diff != "" {
+			t.Fatalf("unexpected remote address (-want +got):\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for connection from added listener")
+	}
+
+	if diff := cmp.Diff(multinet.Addr{tcp1.Addr(), tcp2.Addr()}, l.Addr()); diff != "" {
+		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(2, l.Len()); diff != "" {
+		t.Fatalf("unexpected Len (-want +got):\n%s", diff)
+	}
+}
+
+func TestListenerAddEmpty(t *testing.T) {
+	l := multinet.Listen()
+	defer l.Close()
+
+	if _, err := l.Accept(); !errors.Is(err, multinet.ErrNoListeners) {
+		t.Fatalf("expected ErrNoListeners, but got: %v", err)
+	}
+
+	// Once a listener is added, Accept works as usual.
+	tcp := localListener("tcp")
+	if err := l.Add(tcp); err != nil {
+		t.Fatalf("failed to add listener: %v", err)
+	}
+
+	acceptOne(t, l, tcp.Addr())
+}
+
+func TestListenerAddClosed(t *testing.T) {
+	l := multinet.Listen(localListener("tcp"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+
+	tcp := localListener("tcp")
+	defer tcp.Close()
+
+	if err := l.Add(tcp); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestListenNoListeners(t *testing.T) {
+	// While a Listener constructed with no net.Listeners wouldn't be useful,
+	// we should verify it doesn't panic or similar.
+	l := multinet.Listen()
+
+	if diff := cmp.Diff(multinet.Addr{}, l.Addr()); diff != "" {
+		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	}
+
+	// Close before and after accept to verify sanity.
+	doClose := func() {
+		for i := 0; i < 3; i++ {
+			if err := l.Close(); err != nil {
+				t.Fatalf("failed to close listener: %v", err)
+			}
+		}
+	}
+
+	doClose()
+
+	if c, err := l.Accept(); !errors.Is(err, multinet.ErrNoListeners) || c != nil {
+		t.Fatalf("expected nil net.Conn (got: %#v) and ErrNoListeners (got: %v)", c, err)
+	}
+
+	doClose()
+}
+
+func TestListenerRemove(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		tcp3 = localListener("tcp")
+		tcp4 = localListener("tcp")
+		tcp5 = localListener("tcp")
+		l    = multinet.Listen(tcp1, tcp2, tcp3, tcp4)
+	)
+	defer l.Close()
+
+	// Start the accept goroutines before modifying the Listener.
+	acceptOne(t, l, tcp1.Addr())
+
+	if err := l.Add(tcp5); err != nil {
+		t.Fatalf("failed to add listener: %v", err)
+	}
+	for _, ln := range []net.Listener{tcp2, tcp4} {
+		if err := l.Remove(ln); err != nil {
+			t.Fatalf("failed to remove listener: %v", err)
+		}
+	}
+
+	// The survivors retain their relative order.
+	want := multinet.Addr{tcp1.Addr(), tcp3.Addr(), tcp5.Addr()}
+	if diff := cmp.Diff(want, l.Addr()); diff != "" {
+		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	}
+
+	var addrs multinet.Addr
+	for _, s := range l.Stats() {
+		addrs = append(addrs, s.Addr)
+	}
+	if diff := cmp.Diff(want, addrs); diff != "" {
+		t.Fatalf("unexpected Stats addresses (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(3, l.Len()); diff != "" {
+		t.Fatalf("unexpected Len (-want +got):\n%s", diff)
+	}
+
+	// The removed listeners were closed, and the others still serve.
+	if _, err := tcp2.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected removed listener to be closed, but got: %v", err)
+	}
+	acceptOne(t, l, tcp3.Addr())
+	acceptOne(t, l, tcp5.Addr())
+}
+
+func TestListenerRemoveBeforeAccept(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.NewListener([]net.Listener{tcp1, tcp2}, multinet.WithPrimary(tcp1))
+	)
+	defer l.Close()
+
+	if err := l.Remove(tcp1); err != nil {
+		t.Fatalf("failed to remove listener: %v", err)
+	}
+
+	// The primary was removed, so Addr reports the remaining listener.
+	if diff := cmp.Diff(multinet.Addr{tcp2.Addr()}, l.Addr()); diff != "" {
+		t.Fatalf("unexpected Addr (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(1, l.Len()); diff != "" {
+		t.Fatalf("unexpected Len (-want +got):\n%s", diff)
+	}
+
+	acceptOne(t, l, tcp2.Addr())
+
+	if err := l.Remove(tcp2); err != nil {
+		t.Fatalf("failed to remove listener: %v", err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, multinet.ErrNoListeners) {
+		t.Fatalf("expected ErrNoListeners, but got: %v", err)
+	}
+}
+
+func TestListenerRemoveWithoutListenerClose(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.NewListener(
+			[]net.Listener{tcp1, tcp2},
+			multinet.WithPullMode(),
+			multinet.WithoutListenerClose(),
+		)
+	)
+	defer tcp1.Close()
+	defer tcp2.Close()
+	defer l.Close()
+
+	// The accept goroutines are now waiting on demand or blocked in Accept,
+	// and must be stopped without closing tcp2.
+	acceptOne(t, l, tcp1.Addr())
+
+	if err := l.Remove(tcp2); err != nil {
+		t.Fatalf("failed to remove listener: %v", err)
+	}
+
+	acceptOne(t, tcp2, tcp2.Addr())
+	acceptOne(t, l, tcp1.Addr())
+}
+
+func TestListenerRemoveErrors(t *testing.T) {
+	var (
+		tcp1 = localListener("tcp")
+		tcp2 = localListener("tcp")
+		l    = multinet.Listen(tcp1)
+	)
+	defer tcp2.Close()
+
+	if err := l.Remove(tcp2); err == nil {
+		t.Fatal("expected error removing listener which is not owned")
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	if err := l.Remove(tcp1); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed, but got: %v", err)
+	}
+}
+
+func TestListenerAddrSnapshot(t *testing.T) {
+	l := multinet.Listen(localListener("tcp"))
+	defer l.Close()
+
+	const n = 16
+	var (
+		want multinet.Addr
+		eg   errgroup.Group
+	)
+
+	for i := 0; i < n; i++ {
+		ln := localListener("tcp")
+		want = append(want, ln.Addr())
+
+		eg.Go(func() error { return l.Add(ln) })
+	}
+
+	// Snapshots taken concurrently with Add never shrink.
+	eg.Go(func() error {
+		var prev int
+		for i := 0; i < 100; i++ {
+			addrs := l.AddrSnapshot()
+			if len(addrs) < prev {
+				return fmt.Errorf("snapshot shrank from %d to %d addresses", prev, len(addrs))
+			}
+			prev = len(addrs)
+
+			// The caller owns the returned slice.
+			for j := range addrs {
+				addrs[j] = nil
+			}
+		}
+
+		return nil
+	})
+
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("failed to add listeners: %v", err)
+	}
+
+	addrs := l.AddrSnapshot()
+	if diff := cmp.Diff(n+1, len(addrs)); diff != "" {
+		t.Fatalf("unexpected number of addresses (-want +got):\n%s", diff)
+	}
+
+	got := make(map[string]bool)
+	for _, a := range addrs {
+		got[a.String()] = true
+	}
+	for _, a := range want {
+		if !got[a.String()] {
+			t.Fatalf("snapshot is missing added address %s", a)
+		}
+	}
+}
+
+func TestListenerClosedError(t *testing.T) {
+	errShutdown := errors.New("framework: server shutting down")
+
+	tests := []struct {
+		name string
+		opts []multinet.Option
+		want error
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "nil",
+			opts: []multinet.Option{multinet.WithClosedError(nil)},
+		},
+		{
+			name: "custom",
+			opts: []multinet.Option{multinet.WithClosedError(errShutdown)},
+			want: errShutdown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := multinet.NewListener([]net.Listener{localListener("tcp")}, tt.opts...)
+			if err := l.Close(); err != nil {
+				t.Fatalf("failed to close listener: %v", err)
+			}
+
+			_, err := l.Accept()
+			if !errors.Is(err, net.ErrClosed) {
+				t.Fatalf("expected net.ErrClosed, but got: %v", err)
+			}
+			if tt.want == nil {
+				return
+			}
+
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected custom error, but got: %v", err)
+			}
+			if diff := cmp.Diff(tt.want.Error(), err.Error()); diff != "" {
+				t.Fatalf("unexpected error message (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListenerAcceptTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []multinet.Option
+	}{
+		{
+			name: "eager",
+		},
+		{
+			name: "pull",
+			opts: []multinet.Option{multinet.WithPullMode()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tcp := localListener("tcp")
+			l := multinet.NewListener([]net.Listener{tcp}, tt.opts...)
+			defer l.Close()
+
+			const d = 20 * time.Millisecond
+			start := time.Now()
+			c, err := l.AcceptTimeout(d)
+			if c != nil {
+				t.Fatal("expected no connection")
+			}
+
+			var nerr net.Error
+			if !errors.As(err, &nerr) || !nerr.Timeout() {
+				t.Fatalf("expected timeout net.Error, but got: %v", err)
+			}
+			if !errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Fatalf("expected os.ErrDeadlineExceeded, but got: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < d {
+				t.Fatalf("timed out after %s, before %s", elapsed, d)
+			}
+
+			// The Listener continues to work after a timeout.
+			for i := 0; i < 2; i++ {
+				acceptOne(t, l, tcp.Addr())
+			}
+
+			dc, err := net.Dial("tcp", tcp.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer dc.Close()
+
+			ac, err := l.AcceptTimeout(5 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+			_ = ac.Close()
+		})
+	}
+}
+
+func TestListenerAcceptTimeoutBuffered(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(tcp)
+	defer l.Close()
+
+	acceptOne(t, l, tcp.Addr())
+
+	c, err := net.Dial("tcp", tcp.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	waitStats(t, l, func(ss []multinet.ListenerStats) bool {
+		return ss[0].Accepted == 2
+	})
+
+	// A buffered connection is returned even with no time to wait.
+	ac, err := l.AcceptTimeout(0)
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	_ = ac.Close()
+}
+
+func TestListenRequire(t *testing.T) {
+	if _, err := multinet.ListenRequire(); !errors.Is(err, multinet.ErrNoListeners) {
+		t.Fatalf("expected ErrNoListeners, but got: %v", err)
+	}
+
+	tcp := localListener("tcp")
+	l, err := multinet.ListenRequire(tcp)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	acceptOne(t, l, tcp.Addr())
+}
+
+func TestListenerPullMode(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []multinet.Option
+		want int32
+	}{
+		{
+			// One connection is buffered in the channel and another is held
+			// by the accept goroutine.
+			name: "push",
+			want: 3,
+		},
+		{
+			// Only the connection requested by the caller is accepted.
+			name: "pull",
+			opts: []multinet.Option{multinet.WithPullMode()},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := &countListener{Listener: localListener("tcp")}
+			l := multinet.NewListener([]net.Listener{cl}, tt.opts...)
+			defer l.Close()
+
+			// Queue several connections in the kernel before the first Accept.
+			for i := 0; i < 4; i++ {
+				c, err := net.Dial("tcp", cl.Addr().String())
+				if err != nil {
+					t.Fatalf("failed to dial: %v", err)
+				}
+				defer c.Close()
+			}
+
+			c, err := l.Accept()
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+			_ = c.Close()
+
+			// Allow the accept goroutine time to eagerly accept connections
+			// which haven't been requested by the caller.
+			deadline := time.Now().Add(1 * time.Second)
+			for cl.n.Load() < tt.want && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(50 * time.Millisecond)
+
+			if diff := cmp.Diff(tt.want, cl.n.Load()); diff != "" {
+				t.Fatalf("unexpected accepted connections (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListenerPullModeDemandWithdrawn(t *testing.T) {
+	// The only listener fails permanently while a caller is waiting in
+	// Accept, so that caller's demand must be withdrawn.
+	dead := localListener("tcp")
+	_ = dead.Close()
+
+	l := multinet.NewListener([]net.Listener{dead}, multinet.WithPullMode())
+	defer l.Close()
+
+	if _, err := l.Accept(); !errors.Is(err, multinet.ErrAllListenersClosed) {
+		t.Fatalf("expected ErrAllListenersClosed, but got: %v", err)
+	}
+
+	cl := &countListener{Listener: localListener("tcp")}
+	if err := l.Add(cl); err != nil {
+		t.Fatalf("failed to add listener: %v", err)
+	}
+
+	c, err := net.Dial("tcp", cl.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	// No caller is waiting in Accept, so nothing may be accepted.
+	time.Sleep(100 * time.Millisecond)
+	if diff := cmp.Diff(int32(0), cl.n.Load()); diff != "" {
+		t.Fatalf("unexpected accepted connections (-want +got):\n%s", diff)
+	}
+
+	ac, err := l.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	_ = ac.Close()
+
+	if diff := cmp.Diff(int32(1), cl.n.Load()); diff != "" {
+		t.Fatalf("unexpected accepted connections (-want +got):\n%s", diff)
+	}
+}
+
+// acceptOne dials addr and accepts the connection from l.
+func acceptOne(t *testing.T, l net.Listener, addr net.Addr) {
+	t.Helper()
+
+	c, err := net.Dial(addr.Network(), addr.String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	ac, err := l.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	_ = ac.Close()
+}
+
+// waitLen waits for l.Len to reach n, failing the test after a timeout.
+func waitLen(t *testing.T, l *multinet.Listener, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(1 * time.Second)
+	for l.Len() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for Len %d, got %d", n, l.Len())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitStats(t *testing.T, l *multinet.Listener, ok func(ss []multinet.ListenerStats) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(1 * time.Second)
+	for !ok(l.Stats()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for stats, got %+v", l.Stats())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func compareErrors(x, y error) bool {
+	switch {
+	case x == nil && y == nil:
+		// Both nil.
+		return true
+	case x == nil || y == nil:
+		// One or the other nil.
+		return false
+	default:
+		// Verify by string contents.
+		return x.Error() == y.Error()
+	}
+}
+
+func localListener(network string) net.Listener {
+	l, err := nettest.NewLocalListener(network)
+	if err != nil {
+		panicf("failed to create local listener: %v", err)
+	}
+
+	return l
+}
+
+func httpGet(t *testing.T, addr net.Addr) string {
+	t.Helper()
+
+	var (
+		transport = &http.Transport{}
+		reqAddr   string
+	)
+
+	switch addr.(type) {
+	case *net.TCPAddr:
+		// Send requests to the TCP address of the server.
+		reqAddr = (&url.URL{
+			Scheme: "http",
+			Host:   addr.String(),
+		}).String()
+	case *net.UnixAddr:
+		// Send requests over UNIX socket instead of TCP.
+		transport.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", addr.String())
+		}
+
+		// It seems this just has to be set to something nonsensical since we
+		// are overriding Dial with a known address anyway.
+		reqAddr = "http://foo"
+	default:
+		panicf("unhandled type: %T", addr)
+	}
+
+	c := &http.Client{
+		Timeout:   1 * time.Second,
+		Transport: transport,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, reqAddr, nil)
+	if err != nil {
+		t.Fatalf("failed to create HTTP request: %v", err)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("failed to perform HTTP request: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP 200, but got HTTP %d", res.StatusCode)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		t.Fatalf("failed to read HTTP body: %v", err)
+	}
+
+	return string(b)
+}
+
+func parseAddrIP(s string) net.IP {
+	// Assume s is a host:port string where host is always an IP.
+	host, _, err := net.SplitHostPort(s)
+	if err != nil {
+		panicf("failed to split host/port: %v", err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		panicf("failed to parse IP address: %v", err)
+	}
+
+	return ip
+}
+
+func panicf(format string, a ...interface{}) {
+	panic(fmt.Sprintf(format, a...))
+}
+
+type countListener struct {
+	net.Listener
+	n atomic.Int32
+}
+
+func (l *countListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.n.Add(1)
+	}
+
+	return c, err
+}
+
+type hangListener struct {
+	acceptingC, unblockC chan struct{}
+	once                 sync.Once
+}
+
+func newHangListener() *hangListener {
+	return &hangListener{
+		acceptingC: make(chan struct{}),
+		unblockC:   make(chan struct{}),
+	}
+}
+
+var _ net.Listener = &hangListener{}
+
+func (*hangListener) Addr() net.Addr { return &net.UnixAddr{Net: "unix", Name: "hang"} }
+func (*hangListener) Close() error   { return nil }
+
+func (l *hangListener) Accept() (net.Conn, error) {
+	l.once.Do(func() { close(l.acceptingC) })
+	<-l.unblockC
+	return nil, net.ErrClosed
+}
+
+func (l *hangListener) unblock() { close(l.unblockC) }
+
+// A taggedConn is a net.Conn which records the wrappers applied to it.
+type taggedConn struct {
+	net.Conn
+	tags []string
+}
+
+// An addrListener is a net.Listener which only reports an address.
+type addrListener struct {
+	net.Listener
+	addr net.Addr
+}
+
+func (l *addrListener) Addr() net.Addr { return l.addr }
+
+// A scriptListener is a net.Listener which returns a scripted sequence of
+// results from Accept, where a nil error produces a connection, and then
+// blocks until closed.
+type scriptListener struct {
+	script        []error
+	calls         atomic.Int32
+	closed        atomic.Bool
+	doneC, closeC chan struct{}
+	once          sync.Once
+}
+
+func newScriptListener(script []error) *scriptListener {
+	return &scriptListener{
+		script: script,
+		doneC:  make(chan struct{}),
+		closeC: make(chan struct{}),
+	}
+}
+
+func (l *scriptListener) Accept() (net.Conn, error) {
+	n := int(l.calls.Add(1))
+	if n > len(l.script) {
+		l.calls.Add(-1)
+		close(l.doneC)
+		<-l.closeC
+		return nil, net.ErrClosed
+	}
+
+	if err := l.script[n-1]; err != nil {
+		return nil, err
+	}
+
+	c, _ := net.Pipe()
+	return c, nil
+}
+
+func (*scriptListener) Addr() net.Addr { return &net.UnixAddr{Net: "unix", Name: "script"} }
+
+func (l *scriptListener) Close() error {
+	l.once.Do(func() {
+		l.closed.Store(true)
+		close(l.closeC)
+	})
+	return nil
 }
 
 type errListener struct {
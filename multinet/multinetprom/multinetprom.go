@@ -0,0 +1,99 @@
+// Package multinetprom provides a Prometheus collector for multinet.Listener
+// statistics.
+//
+// This package is separate from package multinet so that users of multinet do
+// not depend on the Prometheus client libraries.
+package multinetprom
+
+import (
+	"github.com/mdlayher/netx/multinet"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "multinet"
+
+var _ prometheus.Collector = &collector{}
+
+// A collector is a prometheus.Collector for a multinet.Listener.
+type collector struct {
+	l *multinet.Listener
+
+	Accepted *prometheus.Desc
+	Errors   *prometheus.Desc
+	Active   *prometheus.Desc
+}
+
+// NewCollector creates a prometheus.Collector which exposes the statistics
+// of each net.Listener owned by l, labeled by the network and address of
+// that net.Listener. See multinet.ListenerStats for details.
+//
+// The active connections gauge is only populated if l was created with
+// multinet.WithConnTracking.
+func NewCollector(l *multinet.Listener) prometheus.Collector {
+	const subsystem = "listener"
+
+	labels := []string{"network", "address"}
+
+	return &collector{
+		l: l,
+
+		Accepted: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "connections_accepted_total"),
+			"The number of connections accepted by a net.Listener.",
+			labels, nil,
+		),
+
+		Errors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "accept_errors_total"),
+			"The number of errors returned by a net.Listener's Accept method.",
+			labels, nil,
+		),
+
+		Active: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "connections_active"),
+			"The number of accepted connections which have not been closed.",
+			labels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ds := []*prometheus.Desc{
+		c.Accepted,
+		c.Errors,
+		c.Active,
+	}
+
+	for _, d := range ds {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.l.Stats() {
+		labels := []string{s.Addr.Network(), s.Addr.String()}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Accepted,
+			prometheus.CounterValue,
+			float64(s.Accepted),
+			labels...,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Errors,
+			prometheus.CounterValue,
+			float64(s.Errors),
+			labels...,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Active,
+			prometheus.GaugeValue,
+			float64(s.Active),
+			labels...,
+		)
+	}
+}
@@ -0,0 +1,51 @@
+package multinetprom_test
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mdlayher/netx/multinet"
+	"github.com/mdlayher/netx/multinet/multinetprom"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	l := multinet.NewListener([]net.Listener{ln}, multinet.WithConnTracking())
+	defer l.Close()
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	ac, err := l.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	defer ac.Close()
+
+	labels := fmt.Sprintf(`{address=%q,network="tcp"}`, ln.Addr().String())
+	want := strings.NewReader(`
+# HELP multinet_listener_accept_errors_total The number of errors returned by a net.Listener's Accept method.
+# TYPE multinet_listener_accept_errors_total counter
+multinet_listener_accept_errors_total` + labels + ` 0
+# HELP multinet_listener_connections_accepted_total The number of connections accepted by a net.Listener.
+# TYPE multinet_listener_connections_accepted_total counter
+multinet_listener_connections_accepted_total` + labels + ` 1
+# HELP multinet_listener_connections_active The number of accepted connections which have not been closed.
+# TYPE multinet_listener_connections_active gauge
+multinet_listener_connections_active` + labels + ` 1
+`)
+
+	if err := testutil.CollectAndCompare(multinetprom.NewCollector(l), want); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package multinet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrNoPacketConns is returned by PacketConn.ReadFrom when the PacketConn does
+// not own any net.PacketConns.
+var ErrNoPacketConns = errors.New("multinet: no net.PacketConns added to PacketConn")
+
+// errMissingAddress is returned by PacketConn.WriteTo when no destination
+// address is provided.
+var errMissingAddress = errors.New("multinet: missing address")
+
+const (
+	// maxDatagram is the size of the buffer used to read each datagram, large
+	// enough for any UDP payload.
+	maxDatagram = 64 * 1024
+
+	// maxPeers bounds the number of peers tracked for routing replies.
+	maxPeers = 1024
+)
+
+// WithPacketRoute configures a PacketConn to call route to choose which of
+// its net.PacketConns should be used to send a datagram to dst in WriteTo.
+// If route returns nil, WriteTo returns an error.
+//
+// By default, WriteTo uses the net.PacketConn which most recently received a
+// datagram from dst, or the first net.PacketConn of the same IP family as dst
+// if no datagrams have been received from dst.
+func WithPacketRoute(route func(dst net.Addr) net.PacketConn) Option {
+	return func(c *config) { c.route = route }
+}
+
+// A PacketConn is a net.PacketConn which aggregates multiple net.PacketConns.
+// The net.PacketConns do not have to be of the same underlying type. Any
+// datagram or error from an individual net.PacketConn will be forwarded to the
+// PacketConn.
+type PacketConn struct {
+	cfg                 config
+	cs                  []net.PacketConn
+	readOnce, closeOnce sync.Once
+	wg                  sync.WaitGroup
+	doneC               chan struct{}
+	readC               chan packet
+
+	mu    sync.Mutex
+	peers map[string]net.PacketConn
+}
+
+var _ net.PacketConn = &PacketConn{}
+
+// ListenPacket creates a PacketConn which aggregates multiple
+// net.PacketConns. Although it is possible to construct a PacketConn with no
+// net.PacketConns, it will always return ErrNoPacketConns on ReadFrom.
+func ListenPacket(cs ...net.PacketConn) *PacketConn { return NewPacketConn(cs) }
+
+// NewPacketConn creates a PacketConn which aggregates multiple
+// net.PacketConns, using the input Options to configure the PacketConn. See
+// ListenPacket for details.
+func NewPacketConn(cs []net.PacketConn, opts ...Option) *PacketConn {
+	var cfg config
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	return &PacketConn{
+		cfg:   cfg,
+		cs:    cs,
+		doneC: make(chan struct{}),
+		readC: make(chan packet, len(cs)),
+		peers: make(map[string]net.PacketConn),
+	}
+}
+
+// ReadFrom reads a datagram from one of the owned net.PacketConns. As with a
+// UDP socket, if p is too small to hold the datagram, the excess is discarded.
+// Once the PacketConn is closed, ReadFrom returns an error which wraps
+// net.ErrClosed.
+func (pc *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if len(pc.cs) == 0 {
+		// No connections, nothing to do.
+		return 0, nil, ErrNoPacketConns
+	}
+
+	pc.readOnce.Do(func() {
+		// On first ReadFrom, create read multiplexing goroutines which will
+		// feed datagrams and errors over pc.readC.
+		pc.wg.Add(len(pc.cs))
+
+		for _, c := range pc.cs {
+			go func(c net.PacketConn) {
+				defer pc.wg.Done()
+				pc.read(c)
+			}(c)
+		}
+	})
+
+	select {
+	case pkt := <-pc.readC:
+		if pkt.err != nil {
+			return 0, nil, pkt.err
+		}
+
+		// Remember which connection this peer used so that replies are routed
+		// back through the same connection.
+		pc.track(pkt.addr, pkt.c)
+		return copy(p, pkt.b), pkt.addr, nil
+	case <-pc.doneC:
+		return 0, nil, errClosed
+	}
+}
+
+// WriteTo writes a datagram to addr using one of the owned net.PacketConns,
+// as selected by the routing policy described in WithPacketRoute. As with the
+// standard library's net.PacketConns, a nil addr returns an error.
+func (pc *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if addr == nil {
+		local := pc.LocalAddr()
+		return 0, &net.OpError{
+			Op:     "write",
+			Net:    local.Network(),
+			Source: local,
+			Err:    errMissingAddress,
+		}
+	}
+
+	var c net.PacketConn
+	if pc.cfg.route != nil {
+		c = pc.cfg.route(addr)
+	} else {
+		c = pc.lookup(addr)
+	}
+
+	if c == nil {
+		return 0, fmt.Errorf("multinet: no net.PacketConn route to %s", addr)
+	}
+
+	return c.WriteTo(p, addr)
+}
+
+// LocalAddr creates a net.Addr of type Addr with all the aggregated addresses
+// of the owned net.PacketConns.
+func (pc *PacketConn) LocalAddr() net.Addr {
+	addrs := make(Addr, 0, len(pc.cs))
+	for _, c := range pc.cs {
+		addrs = append(addrs, c.LocalAddr())
+	}
+
+	return addrs
+}
+
+// SetDeadline sets read and write deadlines t on all net.PacketConns owned by
+// this PacketConn. If more than one net.PacketConn returns an error, only the
+// first error is returned.
+func (pc *PacketConn) SetDeadline(t time.Time) error {
+	return pc.each(func(c net.PacketConn) error { return c.SetDeadline(t) })
+}
+
+// SetReadDeadline sets a read deadline t on all net.PacketConns owned by this
+// PacketConn. If more than one net.PacketConn returns an error, only the first
+// error is returned.
+func (pc *PacketConn) SetReadDeadline(t time.Time) error {
+	return pc.each(func(c net.PacketConn) error { return c.SetReadDeadline(t) })
+}
+
+// SetWriteDeadline sets a write deadline t on all net.PacketConns owned by
+// this PacketConn. If more than one net.PacketConn returns an error, only the
+// first error is returned.
+func (pc *PacketConn) SetWriteDeadline(t time.Time) error {
+	return pc.each(func(c net.PacketConn) error { return c.SetWriteDeadline(t) })
+}
+
+// Close closes all net.PacketConns owned by this PacketConn. If more than one
+// net.PacketConn returns an error, only the first error is returned.
+func (pc *PacketConn) Close() error {
+	var err error
+
+	pc.closeOnce.Do(func() {
+		// On first invocation of Close, halt all read multiplexing goroutines
+		// and Close the individual connections.
+		defer pc.wg.Wait()
+		close(pc.doneC)
+
+		err = pc.each(func(c net.PacketConn) error { return c.Close() })
+	})
+
+	return err
+}
+
+// each invokes fn for each owned net.PacketConn, returning the first error.
+func (pc *PacketConn) each(fn func(c net.PacketConn) error) error {
+	var err error
+	for _, c := range pc.cs {
+		// Only propagate the first returned error to the caller.
+		if cerr := fn(c); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// track records c as the net.PacketConn which received a datagram from addr.
+func (pc *PacketConn) track(addr net.Addr, c net.PacketConn) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	key := peerKey(addr)
+	if _, ok := pc.peers[key]; !ok && len(pc.peers) >= maxPeers {
+		// Make room by evicting an arbitrary peer.
+		for k := range pc.peers {
+			delete(pc.peers, k)
+			break
+		}
+	}
+
+	pc.peers[key] = c
+}
+
+// lookup chooses a net.PacketConn for sending a datagram to addr using the
+// default routing policy.
+func (pc *PacketConn) lookup(addr net.Addr) net.PacketConn {
+	pc.mu.Lock()
+	c, ok := pc.peers[peerKey(addr)]
+	pc.mu.Unlock()
+	if ok {
+		return c
+	}
+
+	// No datagrams from this peer yet, so choose the first connection of the
+	// same IP family.
+	dst, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return nil
+	}
+
+	for _, c := range pc.cs {
+		src, ok := c.LocalAddr().(*net.UDPAddr)
+		if ok && (src.IP.To4() != nil) == (dst.IP.To4() != nil) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// peerKey produces a map key for a peer's address.
+func peerKey(addr net.Addr) string { return addr.Network() + "," + addr.String() }
+
+// A packet is the result of the ReadFrom method.
+type packet struct {
+	b    []byte
+	addr net.Addr
+	c    net.PacketConn
+	err  error
+}
+
+// read begins reading datagrams from c, sending the results to pc.readC.
+func (pc *PacketConn) read(c net.PacketConn) {
+	buf := make([]byte, maxDatagram)
+	for {
+		n, addr, err := c.ReadFrom(buf)
+
+		// Prioritize the done signal over reading a datagram.
+		select {
+		case <-pc.doneC:
+			return
+		default:
+		}
+
+		// buf is reused for the next datagram, so the caller receives a copy.
+		pkt := packet{addr: addr, c: c, err: err}
+		if err == nil {
+			pkt.b = make([]byte, n)
+			copy(pkt.b, buf[:n])
+		}
+
+		select {
+		case <-pc.doneC:
+			return
+		case pc.readC <- pkt:
+		}
+	}
+}
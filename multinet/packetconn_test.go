@@ -0,0 +1,188 @@
+package multinet_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+	"golang.org/x/net/nettest"
+)
+
+func TestPacketConnReadWrite(t *testing.T) {
+	var (
+		udp4 = localPacketConn("udp4")
+		udp6 = localPacketConn("udp6")
+	)
+
+	pc := multinet.ListenPacket(udp4, udp6)
+	defer pc.Close()
+
+	if diff := cmp.Diff("udp,udp", pc.LocalAddr().Network()); diff != "" {
+		t.Fatalf("unexpected networks (-want +got):\n%s", diff)
+	}
+
+	// Echo datagrams back to their senders until the PacketConn is closed.
+	go func() {
+		b := make([]byte, 1024)
+		for {
+			n, addr, err := pc.ReadFrom(b)
+			if err != nil {
+				return
+			}
+
+			if _, err := pc.WriteTo(b[:n], addr); err != nil {
+				panicf("failed to write: %v", err)
+			}
+		}
+	}()
+
+	// Each reply must be routed back through the net.PacketConn which
+	// received the request.
+	for _, c := range []net.PacketConn{udp4, udp6} {
+		t.Run(c.LocalAddr().String(), func(t *testing.T) {
+			from := echo(t, c.LocalAddr(), "hello")
+			if diff := cmp.Diff(c.LocalAddr().String(), from.String()); diff != "" {
+				t.Fatalf("unexpected reply address (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPacketConnRoute(t *testing.T) {
+	var (
+		udp4a = localPacketConn("udp4")
+		udp4b = localPacketConn("udp4")
+	)
+
+	// Force all replies through the second connection.
+	pc := multinet.NewPacketConn(
+		[]net.PacketConn{udp4a, udp4b},
+		multinet.WithPacketRoute(func(_ net.Addr) net.PacketConn { return udp4b }),
+	)
+	defer pc.Close()
+
+	go func() {
+		b := make([]byte, 1024)
+		n, addr, err := pc.ReadFrom(b)
+		if err != nil {
+			return
+		}
+
+		_, _ = pc.WriteTo(b[:n], addr)
+	}()
+
+	from := echo(t, udp4a.LocalAddr(), "hello")
+	if diff := cmp.Diff(udp4b.LocalAddr().String(), from.String()); diff != "" {
+		t.Fatalf("unexpected reply address (-want +got):\n%s", diff)
+	}
+}
+
+func TestPacketConnNoRoute(t *testing.T) {
+	pc := multinet.ListenPacket(localPacketConn("udp4"))
+	defer pc.Close()
+
+	// No datagrams have been received and there is no IPv6 connection.
+	if _, err := pc.WriteTo([]byte("hello"), &net.UDPAddr{IP: net.IPv6loopback, Port: 9}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestPacketConnWriteToNilAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []multinet.Option
+	}{
+		{
+			name: "default route",
+		},
+		{
+			name: "custom route",
+			opts: []multinet.Option{
+				multinet.WithPacketRoute(func(_ net.Addr) net.PacketConn { return nil }),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := multinet.NewPacketConn([]net.PacketConn{localPacketConn("udp4")}, tt.opts...)
+			defer pc.Close()
+
+			_, err := pc.WriteTo([]byte("hello"), nil)
+
+			var oerr *net.OpError
+			if !errors.As(err, &oerr) {
+				t.Fatalf("expected *net.OpError, but got: %v", err)
+			}
+			if diff := cmp.Diff("write", oerr.Op); diff != "" {
+				t.Fatalf("unexpected operation (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPacketConnReadFromClosed(t *testing.T) {
+	pc := multinet.ListenPacket(localPacketConn("udp4"))
+	if err := pc.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if _, _, err := pc.ReadFrom(make([]byte, 1)); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed, but got: %v", err)
+	}
+}
+
+func TestPacketConnNoConns(t *testing.T) {
+	pc := multinet.ListenPacket()
+
+	if _, _, err := pc.ReadFrom(make([]byte, 1)); !errors.Is(err, multinet.ErrNoPacketConns) {
+		t.Fatalf("expected ErrNoPacketConns, but got: %v", err)
+	}
+
+	if err := pc.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+}
+
+// echo sends s to addr and returns the address which sent the reply.
+func echo(t *testing.T, addr net.Addr, s string) net.Addr {
+	t.Helper()
+
+	c, err := net.ListenUDP(addr.Network(), nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.SetDeadline(time.Now().Add(1 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	if _, err := c.WriteTo([]byte(s), addr); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	b := make([]byte, 1024)
+	n, from, err := c.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	if diff := cmp.Diff(s, string(b[:n])); diff != "" {
+		t.Fatalf("unexpected reply (-want +got):\n%s", diff)
+	}
+
+	return from
+}
+
+func localPacketConn(network string) net.PacketConn {
+	c, err := nettest.NewLocalPacketListener(network)
+	if err != nil {
+		panicf("failed to create local packet listener: %v", err)
+	}
+
+	return c
+}
@@ -0,0 +1,67 @@
+package multinet
+
+// Pause stops the Listener from accepting connections without closing its
+// net.Listeners, such as during maintenance. The net.Listeners remain bound,
+// so the operating system continues to queue incoming connections until
+// Resume is called or its backlog is full.
+//
+// While paused, Accept blocks until Resume or Close is called, and the accept
+// goroutines stop calling Accept on the net.Listeners. An accept goroutine
+// which is already blocked in Accept when Pause is called cannot be
+// interrupted, so at most one connection per net.Listener may be accepted
+// after Pause returns; it is held until Resume is called, or closed by Close.
+//
+// Calling Pause on a paused Listener has no effect.
+func (l *Listener) Pause() {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	if l.resumeC != nil {
+		return
+	}
+
+	close(l.pausedC)
+	l.pausedC, l.resumeC = nil, make(chan struct{})
+}
+
+// Resume resumes accepting connections after a call to Pause. Calling Resume
+// on a Listener which is not paused has no effect.
+func (l *Listener) Resume() {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	if l.resumeC == nil {
+		return
+	}
+
+	close(l.resumeC)
+	l.pausedC, l.resumeC = make(chan struct{}), nil
+}
+
+// pauseState returns the channels which signal a change in the paused state
+// of l. resumeC is non-nil only if l is paused.
+func (l *Listener) pauseState() (pausedC, resumeC chan struct{}) {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	return l.pausedC, l.resumeC
+}
+
+// waitResume blocks until l is not paused, reporting false if the Listener
+// was closed or ln was removed in the meantime.
+func (l *Listener) waitResume(ln *listener) bool {
+	for {
+		_, resumeC := l.pauseState()
+		if resumeC == nil {
+			return true
+		}
+
+		select {
+		case <-resumeC:
+		case <-l.doneC:
+			return false
+		case <-ln.removeC:
+			return false
+		}
+	}
+}
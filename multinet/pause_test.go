@@ -0,0 +1,99 @@
+package multinet_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestListenerPause(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(tcp)
+	defer l.Close()
+
+	// Start the accept goroutine, which is then blocked in Accept when the
+	// Listener is paused.
+	acceptOne(t, l, tcp.Addr())
+	l.Pause()
+
+	const n = 3
+	for i := 0; i < n; i++ {
+		c, err := net.Dial(tcp.Addr().Network(), tcp.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+	}
+
+	acceptC := make(chan net.Conn, n)
+	go func() {
+		for i := 0; i < n; i++ {
+			c, err := l.Accept()
+			if err != nil {
+				panicf("failed to accept: %v", err)
+			}
+			acceptC <- c
+		}
+	}()
+
+	// Only the connection accepted by the goroutine which was already blocked
+	// in Accept is taken from the kernel, and Accept delivers nothing.
+	waitStats(t, l, func(ss []multinet.ListenerStats) bool {
+		return ss[0].Accepted == 2
+	})
+
+	select {
+	case <-acceptC:
+		t.Fatal("accepted a connection while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if diff := cmp.Diff(uint64(2), l.Stats()[0].Accepted); diff != "" {
+		t.Fatalf("unexpected accepted count while paused (-want +got):\n%s", diff)
+	}
+
+	// Once resumed, the queued connections are accepted.
+	l.Resume()
+	for i := 0; i < n; i++ {
+		select {
+		case c := <-acceptC:
+			_ = c.Close()
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for queued connections")
+		}
+	}
+}
+
+func TestListenerPauseClose(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(tcp)
+
+	l.Pause()
+	l.Pause()
+
+	errC := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		errC <- err
+	}()
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+
+	select {
+	case err := <-errC:
+		if err == nil {
+			t.Fatal("expected an error after Close, but none occurred")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Accept to return")
+	}
+
+	// Resume after Close has no effect.
+	l.Resume()
+	l.Resume()
+}
@@ -0,0 +1,91 @@
+package multinet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Run accepts connections from l and calls handle for each in its own
+// goroutine until ctx is canceled or Accept returns an error which is not an
+// *AcceptError, such as ErrAllListenersClosed. Before returning, Run closes l
+// and waits for all calls to handle to return. If ctx is canceled, Run returns
+// the context's error, and otherwise it returns the error from Accept.
+//
+// An *AcceptError originates from a single net.Listener which the Listener
+// continues to serve, so Run retries Accept after a brief delay, as
+// net/http.Server does for temporary errors. handle is responsible for
+// closing each net.Conn, and must observe ctx itself if it should return
+// promptly when ctx is canceled.
+func Run(ctx context.Context, l *Listener, handle func(net.Conn)) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer l.Close()
+
+	// Close the Listener to interrupt Accept when ctx is canceled.
+	stopC := make(chan struct{})
+	defer close(stopC)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = l.Close()
+		case <-stopC:
+		}
+	}()
+
+	var b backoff
+	for {
+		c, err := l.Accept()
+		if err == nil {
+			b.reset()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				handle(c)
+			}()
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var aerr *AcceptError
+		if !errors.As(err, &aerr) {
+			return err
+		}
+
+		if !b.wait(ctx.Done()) {
+			return ctx.Err()
+		}
+	}
+}
+
+// A backoff delays retries of Accept after an *AcceptError.
+type backoff struct{ delay time.Duration }
+
+// reset resets the delay after a successful Accept.
+func (b *backoff) reset() { b.delay = 0 }
+
+// wait backs off exponentially while errors persist, reporting false if
+// doneC is closed first.
+func (b *backoff) wait(doneC <-chan struct{}) bool {
+	if b.delay == 0 {
+		b.delay = 5 * time.Millisecond
+	} else if b.delay *= 2; b.delay > 1*time.Second {
+		b.delay = 1 * time.Second
+	}
+
+	timer := time.NewTimer(b.delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-doneC:
+		return false
+	}
+}
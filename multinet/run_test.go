@@ -0,0 +1,123 @@
+package multinet_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestRun(t *testing.T) {
+	var (
+		tcp = localListener("tcp")
+		l   = multinet.Listen(tcp)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Echo a single byte on each connection, and then linger until Run is
+	// canceled to verify that Run waits for its handlers.
+	var handled, exited atomic.Int32
+	errC := make(chan error, 1)
+	go func() {
+		errC <- multinet.Run(ctx, l, func(c net.Conn) {
+			defer c.Close()
+
+			b := make([]byte, 1)
+			if _, err := c.Read(b); err != nil {
+				panicf("failed to read: %v", err)
+			}
+			if _, err := c.Write(b); err != nil {
+				panicf("failed to write: %v", err)
+			}
+			handled.Add(1)
+
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			exited.Add(1)
+		})
+	}()
+
+	for i := 0; i < 3; i++ {
+		c, err := net.Dial("tcp", tcp.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+
+		if _, err := c.Write([]byte{byte(i)}); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+
+		b := make([]byte, 1)
+		if _, err := c.Read(b); err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if diff := cmp.Diff(byte(i), b[0]); diff != "" {
+			t.Fatalf("unexpected echo (-want +got):\n%s", diff)
+		}
+	}
+
+	cancel()
+	if err := <-errC; !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected Run error: %v", err)
+	}
+
+	if diff := cmp.Diff([]int32{3, 3}, []int32{handled.Load(), exited.Load()}); diff != "" {
+		t.Fatalf("unexpected handled and exited counts (-want +got):\n%s", diff)
+	}
+
+	// The Listener and its net.Listeners are closed.
+	if _, err := tcp.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("unexpected accept error: %v", err)
+	}
+}
+
+func TestRunAcceptError(t *testing.T) {
+	// An error from a single net.Listener is skipped, and the following
+	// connection is handled.
+	sl := newScriptListener([]error{errors.New("transient"), nil})
+	l := multinet.Listen(sl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handledC := make(chan struct{})
+	errC := make(chan error, 1)
+	go func() {
+		errC <- multinet.Run(ctx, l, func(c net.Conn) {
+			_ = c.Close()
+			close(handledC)
+		})
+	}()
+
+	select {
+	case <-handledC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for connection to be handled")
+	}
+
+	cancel()
+	if err := <-errC; !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected Run error: %v", err)
+	}
+}
+
+func TestRunAllListenersClosed(t *testing.T) {
+	tcp := localListener("tcp")
+	l := multinet.Listen(tcp)
+	_ = tcp.Close()
+
+	err := multinet.Run(context.Background(), l, func(net.Conn) {
+		panic("no connections should be handled")
+	})
+	if !errors.Is(err, multinet.ErrAllListenersClosed) {
+		t.Fatalf("unexpected Run error: %v", err)
+	}
+}
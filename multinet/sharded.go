@@ -0,0 +1,54 @@
+package multinet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// ListenSharded creates a Listener which aggregates shards stream listeners
+// bound to the same network and address, such as "tcp" and ":8080". On Linux,
+// the listeners are bound with SO_REUSEPORT so that the kernel distributes
+// incoming connections between them, which allows accepting connections to
+// scale across CPUs. ctx is used only while binding the listeners.
+//
+// If the port in address is 0, the first listener is bound to an ephemeral port
+// and the remaining listeners are bound to the same port.
+//
+// On platforms other than Linux, an error is returned if shards is greater
+// than 1. If any bind fails, the listeners already bound are closed and an
+// error is returned.
+func ListenSharded(ctx context.Context, network, address string, shards int) (*Listener, error) {
+	if shards < 1 {
+		return nil, fmt.Errorf("multinet: invalid number of shards: %d", shards)
+	}
+	if shards > 1 && !reusePortSupported {
+		return nil, fmt.Errorf("multinet: sharded listeners are not supported on %s", runtime.GOOS)
+	}
+
+	lc := net.ListenConfig{Control: reusePort}
+
+	ls := make([]net.Listener, 0, shards)
+	for i := 0; i < shards; i++ {
+		ln, err := lc.Listen(ctx, network, address)
+		if err != nil {
+			for _, ln := range ls {
+				_ = ln.Close()
+			}
+
+			return nil, fmt.Errorf("multinet: failed to listen on shard %d of %s %s: %w",
+				i, network, address, err)
+		}
+
+		if i == 0 {
+			// Bind the remaining shards to the same address, including any
+			// ephemeral port chosen for the first.
+			address = ln.Addr().String()
+		}
+
+		ls = append(ls, ln)
+	}
+
+	return Listen(ls...), nil
+}
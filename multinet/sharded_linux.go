@@ -0,0 +1,25 @@
+//go:build linux
+
+package multinet
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reusePortSupported reports whether reusePort enables SO_REUSEPORT.
+const reusePortSupported = true
+
+// reusePort is a net.ListenConfig Control function which enables SO_REUSEPORT
+// on a socket.
+func reusePort(_, _ string, c syscall.RawConn) error {
+	var serr error
+	if err := c.Control(func(fd uintptr) {
+		serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	}); err != nil {
+		return err
+	}
+
+	return serr
+}
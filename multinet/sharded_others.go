@@ -0,0 +1,11 @@
+//go:build !linux
+
+package multinet
+
+import "syscall"
+
+// reusePortSupported reports whether reusePort enables SO_REUSEPORT.
+const reusePortSupported = false
+
+// reusePort is a no-op net.ListenConfig Control function on this platform.
+var reusePort func(network, address string, c syscall.RawConn) error
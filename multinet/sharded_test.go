@@ -0,0 +1,95 @@
+package multinet_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestListenSharded(t *testing.T) {
+	const shards = 4
+
+	l, err := multinet.ListenSharded(context.Background(), "tcp", "localhost:0", shards)
+	if runtime.GOOS != "linux" {
+		if err == nil {
+			_ = l.Close()
+			t.Fatal("expected an error, but none occurred")
+		}
+
+		t.Skipf("skipping, sharded listeners are not supported on %s", runtime.GOOS)
+	}
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	addrs := l.Addrs()
+	if diff := cmp.Diff(shards, len(addrs)); diff != "" {
+		t.Fatalf("unexpected number of shards (-want +got):\n%s", diff)
+	}
+
+	// All shards share the same address.
+	for _, addr := range addrs[1:] {
+		if diff := cmp.Diff(addrs[0].String(), addr.String()); diff != "" {
+			t.Fatalf("unexpected shard address (-want +got):\n%s", diff)
+		}
+	}
+
+	for i := 0; i < shards; i++ {
+		acceptOne(t, l, addrs[0])
+	}
+}
+
+func TestListenShardedInvalid(t *testing.T) {
+	if _, err := multinet.ListenSharded(context.Background(), "tcp", "localhost:0", 0); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func BenchmarkListenSharded(b *testing.B) {
+	for _, shards := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("shards %d", shards), func(b *testing.B) {
+			l, err := multinet.ListenSharded(context.Background(), "tcp", "localhost:0", shards)
+			if err != nil {
+				b.Skipf("skipping, failed to listen: %v", err)
+			}
+			defer l.Close()
+
+			// Accept and immediately close connections until the Listener is
+			// closed.
+			go func() {
+				for {
+					c, err := l.Accept()
+					if err != nil {
+						return
+					}
+					_ = c.Close()
+				}
+			}()
+
+			addr := l.Addrs()[0].String()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c, err := net.Dial("tcp", addr)
+					if err != nil {
+						panicf("failed to dial: %v", err)
+					}
+
+					// Wait for the server to close the connection.
+					_, _ = io.Copy(io.Discard, c)
+					_ = c.Close()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package multinet
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithConnTracking configures a Listener to track the number of connections
+// from each net.Listener which are still open, as reported by the Active field
+// of ListenerStats.
+//
+// To do so, each accepted net.Conn is wrapped in a type which decrements the
+// count when Close is called. The wrapper is applied before any wrappers added
+// by WithConnWrapper, but type assertions on the net.Conns returned by
+// Listener.Accept will not observe the original type, such as *net.TCPConn.
+func WithConnTracking() Option {
+	return func(c *config) { c.track = true }
+}
+
+// WithLatencyTracking configures a Listener to measure the latency between
+// each net.Listener's Accept method returning a connection and that
+// connection being returned to the caller by Listener.Accept, as reported by
+// the Latency field of ListenerStats. High latency indicates that the caller
+// of Accept is not keeping up with the owned net.Listeners.
+//
+// Latency tracking reads the clock twice for each connection, so it is
+// disabled by default.
+func WithLatencyTracking() Option {
+	return func(c *config) { c.latency = true }
+}
+
+// LatencyStats summarizes the accept latencies measured for a net.Listener by
+// WithLatencyTracking.
+type LatencyStats struct {
+	// Count is the number of connections measured. The remaining fields are
+	// zero if Count is zero.
+	Count uint64
+
+	// Min, Max, and Mean are the minimum, maximum, and mean latencies
+	// between the net.Listener's Accept method returning a connection and
+	// Listener.Accept returning it to the caller.
+	Min, Max, Mean time.Duration
+}
+
+// ListenerStats contains statistics for a single net.Listener owned by a
+// Listener.
+type ListenerStats struct {
+	// Addr is the address of the net.Listener, and Name is its name if it
+	// was added to the Listener using Named.
+	Addr net.Addr
+	Name string
+
+	// Accepted is the number of connections accepted by the net.Listener.
+	Accepted uint64
+
+	// Errors is the number of errors returned by the net.Listener's Accept
+	// method, excluding the error which occurs when it is closed.
+	Errors uint64
+
+	// Active is the number of accepted connections which have not yet been
+	// closed, and PeakActive is the largest value of Active observed since
+	// the net.Listener was added to the Listener. Both are zero unless
+	// WithConnTracking is used.
+	Active, PeakActive int64
+
+	// Removed reports whether the net.Listener was removed from service after
+	// exhausting the budget set by WithErrorBudget.
+	Removed bool
+
+	// Latency summarizes the latency of delivering connections from the
+	// net.Listener to the caller of Listener.Accept. It is zero unless
+	// WithLatencyTracking is used.
+	Latency LatencyStats
+}
+
+// Stats returns a snapshot of the statistics for each net.Listener owned by
+// this Listener, in the same order as Addr.
+func (l *Listener) Stats() []ListenerStats {
+	ls := l.listeners()
+	ss := make([]ListenerStats, 0, len(ls))
+	for _, ln := range ls {
+		ss = append(ss, ListenerStats{
+			Addr:       ln.Addr(),
+			Name:       ln.name,
+			Accepted:   ln.accepted.Load(),
+			Errors:     ln.errors.Load(),
+			Active:     ln.active.Load(),
+			PeakActive: ln.peak.Load(),
+			Removed:    ln.removed.Load(),
+			Latency:    ln.latencyStats(),
+		})
+	}
+
+	return ss
+}
+
+// FileCount returns the number of file descriptors held by the net.Listeners
+// owned by this Listener, counting one for each net.Listener with a File
+// method, such as *net.TCPListener and *net.UnixListener. Other
+// net.Listeners, and net.Listeners removed by WithErrorBudget, are skipped.
+// If a File method returns an error, or the Listener is closed, FileCount
+// returns an error.
+//
+// File duplicates the net.Listener's file descriptor, which FileCount closes
+// immediately, so FileCount briefly uses additional file descriptors and
+// should be called sparingly, such as when scraping metrics.
+func (l *Listener) FileCount() (int, error) {
+	if isClosed(l.doneC) {
+		return 0, errClosed
+	}
+
+	var n int
+	for _, ln := range l.listeners() {
+		fl, ok := ln.Listener.(interface{ File() (*os.File, error) })
+		if !ok || ln.removed.Load() {
+			continue
+		}
+
+		f, err := fl.File()
+		if err != nil {
+			return 0, fmt.Errorf("multinet: failed to get file for net.Listener %s: %w", ln.Addr(), err)
+		}
+		_ = f.Close()
+
+		n++
+	}
+
+	return n, nil
+}
+
+// QueueDepth returns the number of accepted connections and errors which are
+// buffered awaiting a call to Accept. A QueueDepth which stays near
+// QueueCapacity indicates that the caller of Accept is not keeping up with
+// the owned net.Listeners.
+func (l *Listener) QueueDepth() int { return len(l.acceptC) }
+
+// QueueCapacity returns the number of accepted connections and errors which
+// can be buffered awaiting a call to Accept. The capacity is set to the
+// number of net.Listeners passed to the Listener when it is created, and is 0
+// when WithPullMode is used.
+func (l *Listener) QueueCapacity() int { return cap(l.acceptC) }
+
+// stats counts the results of Accept for a single net.Listener.
+type stats struct {
+	accepted, errors atomic.Uint64
+	active, peak     atomic.Int64
+
+	// mu guards conns, the set of tracked connections which are still open,
+	// and the latency summary.
+	mu    sync.Mutex
+	conns map[*trackedConn]struct{}
+
+	latCount                 uint64
+	latMin, latMax, latTotal time.Duration
+}
+
+// observeLatency records the accept latency d of a single connection.
+func (s *stats) observeLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.latCount == 0 || d < s.latMin {
+		s.latMin = d
+	}
+	if d > s.latMax {
+		s.latMax = d
+	}
+
+	s.latCount++
+	s.latTotal += d
+}
+
+// latencyStats returns a summary of the recorded accept latencies.
+func (s *stats) latencyStats() LatencyStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.latCount == 0 {
+		return LatencyStats{}
+	}
+
+	return LatencyStats{
+		Count: s.latCount,
+		Min:   s.latMin,
+		Max:   s.latMax,
+		Mean:  s.latTotal / time.Duration(s.latCount),
+	}
+}
+
+// observe records the result of a call to Accept, returning a tracked
+// net.Conn if track is set.
+func (s *stats) observe(c net.Conn, err error, track bool) net.Conn {
+	if err != nil {
+		s.errors.Add(1)
+		return c
+	}
+
+	s.accepted.Add(1)
+	if !track {
+		return c
+	}
+
+	tc := &trackedConn{Conn: c, s: s}
+
+	s.mu.Lock()
+	if s.conns == nil {
+		s.conns = make(map[*trackedConn]struct{})
+	}
+	s.conns[tc] = struct{}{}
+	s.mu.Unlock()
+
+	// Raise the peak if this connection exceeds it.
+	n := s.active.Add(1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	return tc
+}
+
+// closeAll closes all tracked connections which are still open.
+func (s *stats) closeAll() {
+	s.mu.Lock()
+	cs := make([]*trackedConn, 0, len(s.conns))
+	for c := range s.conns {
+		cs = append(cs, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range cs {
+		_ = c.Close()
+	}
+}
+
+// A trackedConn is a net.Conn which decrements its active count on Close.
+type trackedConn struct {
+	net.Conn
+	s    *stats
+	once sync.Once
+}
+
+// Close implements net.Conn.
+func (c *trackedConn) Close() error {
+	c.once.Do(func() {
+		c.s.mu.Lock()
+		delete(c.s.conns, c)
+		c.s.mu.Unlock()
+
+		c.s.active.Add(-1)
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,258 @@
+package multinet_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/multinet"
+)
+
+func TestListenerStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []multinet.Option
+		active int64
+	}{
+		{
+			name: "untracked",
+		},
+		{
+			name:   "tracked",
+			opts:   []multinet.Option{multinet.WithConnTracking()},
+			active: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tcp1 = localListener("tcp")
+				tcp2 = localListener("tcp")
+				l    = multinet.NewListener([]net.Listener{tcp1, tcp2}, tt.opts...)
+			)
+			defer l.Close()
+
+			// Accept and close two connections from the first listener, and
+			// leave one connection from the second listener open.
+			acceptOne(t, l, tcp1.Addr())
+			acceptOne(t, l, tcp1.Addr())
+
+			c, err := net.Dial("tcp", tcp2.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+
+			ac, err := l.Accept()
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+			defer ac.Close()
+
+			want := []multinet.ListenerStats{
+				{
+					Addr:       tcp1.Addr(),
+					Accepted:   2,
+					PeakActive: tt.active,
+				},
+				{
+					Addr:       tcp2.Addr(),
+					Accepted:   1,
+					Active:     tt.active,
+					PeakActive: tt.active,
+				},
+			}
+
+			if diff := cmp.Diff(want, l.Stats()); diff != "" {
+				t.Fatalf("unexpected stats (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListenerStatsPeakActive(t *testing.T) {
+	var (
+		tcp = localListener("tcp")
+		l   = multinet.NewListener([]net.Listener{tcp}, multinet.WithConnTracking())
+	)
+	defer l.Close()
+
+	accept := func() net.Conn {
+		t.Helper()
+
+		c, err := net.Dial("tcp", tcp.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+
+		ac, err := l.Accept()
+		if err != nil {
+			t.Fatalf("failed to accept: %v", err)
+		}
+
+		return ac
+	}
+
+	// Hold three connections open at once, close two, and then open one more.
+	cs := []net.Conn{accept(), accept(), accept()}
+	_ = cs[0].Close()
+	_ = cs[1].Close()
+	defer cs[2].Close()
+
+	c := accept()
+	defer c.Close()
+
+	ss := l.Stats()
+	if diff := cmp.Diff([]int64{2, 3}, []int64{ss[0].Active, ss[0].PeakActive}); diff != "" {
+		t.Fatalf("unexpected active and peak active connections (-want +got):\n%s", diff)
+	}
+}
+
+func TestListenerStatsLatency(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []multinet.Option
+		count uint64
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:  "enabled",
+			opts:  []multinet.Option{multinet.WithLatencyTracking()},
+			count: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tcp := localListener("tcp")
+			l := multinet.NewListener([]net.Listener{tcp}, tt.opts...)
+			defer l.Close()
+
+			acceptOne(t, l, tcp.Addr())
+			acceptOne(t, l, tcp.Addr())
+
+			// Leave a connection buffered for a while before accepting it, so
+			// that its latency reflects the delay.
+			c, err := net.Dial("tcp", tcp.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+
+			waitStats(t, l, func(ss []multinet.ListenerStats) bool {
+				return ss[0].Accepted == 3
+			})
+
+			const delay = 20 * time.Millisecond
+			time.Sleep(delay)
+
+			ac, err := l.Accept()
+			if err != nil {
+				t.Fatalf("failed to accept: %v", err)
+			}
+			_ = ac.Close()
+
+			lat := l.Stats()[0].Latency
+			if diff := cmp.Diff(tt.count, lat.Count); diff != "" {
+				t.Fatalf("unexpected latency count (-want +got):\n%s", diff)
+			}
+			if tt.count == 0 {
+				if diff := cmp.Diff(multinet.LatencyStats{}, lat); diff != "" {
+					t.Fatalf("unexpected latency stats (-want +got):\n%s", diff)
+				}
+				return
+			}
+
+			if lat.Min < 0 || lat.Min > lat.Mean || lat.Mean > lat.Max {
+				t.Fatalf("inconsistent latency stats: %+v", lat)
+			}
+			if lat.Max < delay {
+				t.Fatalf("maximum latency %s is less than buffered delay %s", lat.Max, delay)
+			}
+		})
+	}
+}
+
+func TestListenerQueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []multinet.Option
+		depth    int
+		capacity int
+	}{
+		{
+			name:     "buffered",
+			depth:    2,
+			capacity: 2,
+		},
+		{
+			name: "pull",
+			opts: []multinet.Option{multinet.WithPullMode()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tcp1 = localListener("tcp")
+				tcp2 = localListener("tcp")
+				l    = multinet.NewListener([]net.Listener{tcp1, tcp2}, tt.opts...)
+			)
+			defer l.Close()
+
+			// Start the accept goroutines, and then dial one connection to
+			// each listener without accepting them.
+			acceptOne(t, l, tcp1.Addr())
+
+			for _, addr := range []net.Addr{tcp1.Addr(), tcp2.Addr()} {
+				c, err := net.Dial(addr.Network(), addr.String())
+				if err != nil {
+					t.Fatalf("failed to dial: %v", err)
+				}
+				defer c.Close()
+			}
+
+			waitStats(t, l, func(ss []multinet.ListenerStats) bool {
+				return ss[0].Accepted+ss[1].Accepted == uint64(1+tt.depth)
+			})
+
+			if diff := cmp.Diff(tt.depth, l.QueueDepth()); diff != "" {
+				t.Fatalf("unexpected queue depth (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.capacity, l.QueueCapacity()); diff != "" {
+				t.Fatalf("unexpected queue capacity (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListenerFileCount(t *testing.T) {
+	var (
+		tcp  = localListener("tcp")
+		unix = localListener("unix")
+		l    = multinet.Listen(tcp, unix, plainListener{localListener("tcp")})
+	)
+
+	n, err := l.FileCount()
+	if err != nil {
+		t.Fatalf("failed to count files: %v", err)
+	}
+
+	// The plainListener has no File method and is skipped.
+	if diff := cmp.Diff(2, n); diff != "" {
+		t.Fatalf("unexpected file count (-want +got):\n%s", diff)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+
+	if _, err := l.FileCount(); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
@@ -5,9 +5,15 @@ import (
 	"crypto/sha1"
 	"encoding/binary"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"math"
+	"math/bits"
 	"net"
+	"net/netip"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,6 +25,10 @@ var ula = &net.IPNet{
 
 // A Prefix represents a Local IPv6 Unicast Address prefix, as described in
 // RFC 4193, section 3.1.
+//
+// A Prefix created as a struct literal has no explicit length: it is a /48 if
+// SubnetID is zero and a /64 otherwise, as decided on first use. Use
+// NewPrefix48 or NewPrefix64 to set the length explicitly.
 type Prefix struct {
 	// Local indicates if the prefix is locally assigned.
 	Local bool
@@ -31,40 +41,103 @@ type Prefix struct {
 
 	// mask is the Prefix's length. It is set to either /48 or /64 when IPNet or
 	// Subnet is called, depending on whether the Prefix was created by Generate
-	// or manually by the caller.
+	// or manually by the caller. Parse may also set a /56 mask for prefixes
+	// delegated at that length.
 	mask net.IPMask
 }
 
+// NewPrefix48 produces a /48 Prefix from its local flag and global ID, with
+// an explicit length rather than one decided on first use.
+func NewPrefix48(local bool, globalID [5]byte) *Prefix {
+	return &Prefix{
+		Local:    local,
+		GlobalID: globalID,
+		mask:     net.CIDRMask(48, 128),
+	}
+}
+
+// NewPrefix64 produces a /64 Prefix from its local flag, global ID, and subnet
+// ID. Unlike a Prefix struct literal, its length is /64 even if subnetID is
+// zero.
+func NewPrefix64(local bool, globalID [5]byte, subnetID uint16) *Prefix {
+	return &Prefix{
+		Local:    local,
+		GlobalID: globalID,
+		SubnetID: subnetID,
+		mask:     net.CIDRMask(64, 128),
+	}
+}
+
 // IPNet produces a *net.IPNet prefix value from a Prefix.
 func (p *Prefix) IPNet() *net.IPNet {
+	ip := p.array()
+	return &net.IPNet{
+		IP:   ip[:],
+		Mask: p.ipMask(),
+	}
+}
+
+// Addr returns the network address of a Prefix, such as "fd00::" for the
+// Prefix "fd00::/48". Any subnet ID bits outside of the Prefix's current mask
+// are cleared.
+func (p *Prefix) Addr() netip.Addr {
+	ip, mask := p.array(), p.ipMask()
+	for i := range mask {
+		ip[i] &= mask[i]
+	}
+
+	return netip.AddrFrom16(ip)
+}
+
+// array produces the 16 byte address of a Prefix.
+func (p *Prefix) array() [16]byte {
 	// Finalize the computation started by Generate:
 	//
 	// "6) Concatenate FC00::/7, the L bit set to 1, and the 40-bit Global
 	// ID to create a Local IPv6 address prefix."
-	ip := net.IP{0: 0xfc, 15: 0x00}
+	ip := [16]byte{0: 0xfc}
 	if p.Local {
 		ip[0] |= 0x01
 	}
 
 	copy(ip[1:6], p.GlobalID[:])
 
-	// Also set the subnet ID portion. If this Prefix was produced by Generate
-	// and no subnet ID and mask were previously assigned, we will produce a /48.
-	//
-	// However, if Prefix.Subnet was called, all subsequent calls will produce
-	// a /64 subnet within the parent /48 prefix.
+	// Also set the subnet ID portion.
 	binary.BigEndian.PutUint16(ip[6:8], p.SubnetID)
+	return ip
+}
+
+// ipMask returns the mask of a Prefix. If this Prefix was produced by Generate
+// and no subnet ID and mask were previously assigned, we will produce a /48.
+//
+// However, if Prefix.Subnet was called, all subsequent calls will produce a /64
+// subnet within the parent /48 prefix.
+func (p *Prefix) ipMask() net.IPMask {
 	if p.mask == nil {
-		if p.SubnetID == 0 {
-			p.mask = net.CIDRMask(48, 128)
-		} else {
-			p.mask = net.CIDRMask(64, 128)
-		}
+		p.mask = p.Mask()
 	}
 
-	return &net.IPNet{
-		IP:   ip,
-		Mask: p.mask,
+	return p.mask
+}
+
+// Mask returns the effective mask of a Prefix without modifying it, giving
+// read access to the length of the Prefix without calling IPNet.
+//
+// If the length of the Prefix has been decided, such as by Parse, NewPrefix48,
+// NewPrefix64, Subnet, or a previous call to a method such as IPNet, that
+// length is returned. Otherwise, as for a Prefix created as a struct literal,
+// Mask returns a /48 mask if SubnetID is zero and a /64 mask otherwise, but
+// does not decide the length: it is still decided by the first call to a
+// method such as IPNet. The returned mask is a copy which the caller may
+// modify.
+func (p *Prefix) Mask() net.IPMask {
+	switch {
+	case p.mask != nil:
+		return append(net.IPMask(nil), p.mask...)
+	case p.SubnetID == 0:
+		return net.CIDRMask(48, 128)
+	default:
+		return net.CIDRMask(64, 128)
 	}
 }
 
@@ -73,6 +146,10 @@ func (p *Prefix) IPNet() *net.IPNet {
 // If p is a /48 Prefix, the new /64 Prefix will be a child of that parent
 // /48 Prefix.
 //
+// If p is a /56 Prefix, only the low 8 bits of the subnet ID are available
+// to the new /64 Prefix, which will be a child of that parent /56 Prefix. Any
+// higher bits of id are ignored; use SubnetChecked to reject them instead.
+//
 // If p is a /64 Prefix (typically produced through a previous call to Subnet),
 // the new /64 Prefix will be a sibling /64 Prefix of the source /64 Prefix.
 func (p *Prefix) Subnet(id uint16) *Prefix {
@@ -80,30 +157,349 @@ func (p *Prefix) Subnet(id uint16) *Prefix {
 	pp := *p
 	pp.SubnetID = id
 	pp.mask = net.CIDRMask(64, 128)
+
+	// Only Parse can produce a /56, so there is no need to consult the lazy
+	// /48 or /64 logic in IPNet.
+	if ones, _ := p.mask.Size(); ones == 56 {
+		// A /56 delegation fixes the upper 8 bits of the subnet ID, so the
+		// child ID occupies only the lower 8 bits.
+		pp.SubnetID = p.SubnetID&0xff00 | id&0xff
+	}
+
 	return &pp
 }
 
+// SubnetChecked is like Subnet, but returns an error if p is a /56 Prefix and
+// id is 256 or greater, as the subnet would otherwise fall outside of the
+// parent Prefix.
+func (p *Prefix) SubnetChecked(id uint16) (*Prefix, error) {
+	if ones, _ := p.mask.Size(); ones == 56 && id > 0xff {
+		return nil, fmt.Errorf("rfc4193: subnet ID %#04x out of range for /56 prefix %s", id, p)
+	}
+
+	return p.Subnet(id), nil
+}
+
+// HostAt produces the IPv6 address within a /64 Prefix whose interface
+// identifier is n, in big-endian byte order. For example, HostAt(1) on the
+// Prefix "fd00::/64" produces "fd00::1". It returns an error if p is not a /64
+// Prefix. Every uint64 value of n is a valid host, so n cannot overflow the
+// 64-bit interface identifier.
+func (p *Prefix) HostAt(n uint64) (net.IP, error) {
+	if ones, _ := p.ipMask().Size(); ones != 64 {
+		return nil, fmt.Errorf("rfc4193: host addresses require a /64 prefix: %s", p)
+	}
+
+	ip := p.array()
+	binary.BigEndian.PutUint64(ip[8:], n)
+	return ip[:], nil
+}
+
+// maxHosts is the maximum number of addresses produced by Prefix.Hosts.
+const maxHosts = 1 << 16
+
+// Hosts produces an iterator over the first count sequential host addresses
+// of a /64 Prefix, as produced by HostAt(0) through HostAt(count-1), beginning
+// with the Subnet-Router anycast address. Iteration stops early if yield
+// returns false. This is useful for simulating duplicate address detection or
+// populating test fixtures.
+//
+// count is capped at 65536 addresses, and a count of 0 or less produces no
+// addresses. As with HostAt, host addresses require a /64 Prefix, so Hosts
+// produces no addresses if p is not a /64 Prefix.
+func (p *Prefix) Hosts(count int) func(yield func(net.IP) bool) {
+	if ones, _ := p.ipMask().Size(); ones != 64 {
+		count = 0
+	}
+	if count > maxHosts {
+		count = maxHosts
+	}
+
+	return func(yield func(net.IP) bool) {
+		for i := 0; i < count; i++ {
+			// HostAt cannot fail for a /64 Prefix.
+			ip, _ := p.HostAt(uint64(i))
+			if !yield(ip) {
+				return
+			}
+		}
+	}
+}
+
+// maxSubnets is the number of /64 subnets within a /48 Prefix.
+const maxSubnets = 1 << 16
+
+// Split produces n contiguous /64 Prefixes within a /48 Prefix, starting at
+// subnet ID 0. Each returned Prefix is independent of p and of the others.
+//
+// Split returns an error if p is not a /48 Prefix or if n is negative or
+// exceeds the 65536 /64 subnets available within a /48 Prefix.
+func (p *Prefix) Split(n int) ([]*Prefix, error) {
+	if ones, _ := p.IPNet().Mask.Size(); ones != 48 {
+		return nil, fmt.Errorf("rfc4193: can only split a /48 prefix: %s", p)
+	}
+	if n < 0 || n > maxSubnets {
+		return nil, fmt.Errorf("rfc4193: invalid number of /64 subnets: %d", n)
+	}
+
+	ps := make([]*Prefix, 0, n)
+	for i := 0; i < n; i++ {
+		ps = append(ps, p.Subnet(uint16(i)))
+	}
+
+	return ps, nil
+}
+
+// An Allocator allocates the /64 subnets of a /48 Prefix, tracking which of
+// the 65536 subnet IDs are reserved. Its methods are safe for concurrent use.
+type Allocator struct {
+	p *Prefix
+
+	mu   sync.Mutex
+	used [maxSubnets / 64]uint64
+}
+
+// NewAllocator creates an Allocator for the /64 subnets of p, with no subnets
+// reserved. It returns an error if p is not a /48 Prefix.
+func NewAllocator(p *Prefix) (*Allocator, error) {
+	if ones, _ := p.ipMask().Size(); ones != 48 {
+		return nil, fmt.Errorf("rfc4193: can only allocate subnets of a /48 prefix: %s", p)
+	}
+
+	// Copy p so the caller cannot modify it.
+	pp := *p
+	return &Allocator{p: &pp}, nil
+}
+
+// Reserve reserves the free /64 subnet with the lowest subnet ID and returns
+// it. If every subnet is reserved, Reserve returns an error.
+func (a *Allocator) Reserve() (*Prefix, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, w := range a.used {
+		if w == math.MaxUint64 {
+			continue
+		}
+
+		bit := bits.TrailingZeros64(^w)
+		a.used[i] |= 1 << bit
+		return a.p.Subnet(uint16(i*64 + bit)), nil
+	}
+
+	return nil, fmt.Errorf("rfc4193: all %d /64 subnets of %s are reserved", maxSubnets, a.p)
+}
+
+// ReserveID reserves the /64 subnet with subnet ID id, such as one which was
+// allocated before the Allocator was created. It returns an error if the
+// subnet is already reserved.
+func (a *Allocator) ReserveID(id uint16) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i, mask := id/64, uint64(1)<<(id%64)
+	if a.used[i]&mask != 0 {
+		return fmt.Errorf("rfc4193: subnet %s is already reserved", a.p.Subnet(id))
+	}
+
+	a.used[i] |= mask
+	return nil
+}
+
+// Release releases the /64 subnet with subnet ID id so that it can be
+// reserved again. Releasing a subnet which is not reserved has no effect.
+func (a *Allocator) Release(id uint16) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.used[id/64] &^= 1 << (id % 64)
+}
+
+// Aggregate reports whether the /64 Prefixes a and b can be summarized by a
+// common /48 parent Prefix, returning that parent if so. a and b can be
+// aggregated if they share the same global ID and local flag. If either a or
+// b is not a /64 Prefix, Aggregate returns false.
+func Aggregate(a, b *Prefix) (*Prefix, bool) {
+	for _, p := range []*Prefix{a, b} {
+		if ones, _ := p.ipMask().Size(); ones != 64 {
+			return nil, false
+		}
+	}
+
+	if a.Local != b.Local || a.GlobalID != b.GlobalID {
+		return nil, false
+	}
+
+	return &Prefix{
+		Local:    a.Local,
+		GlobalID: a.GlobalID,
+		mask:     net.CIDRMask(48, 128),
+	}, true
+}
+
+// FreeSubnets produces an iterator over the /64 subnets of a Prefix whose
+// subnet IDs are not present in allocated, in ascending order of subnet ID.
+// Iteration stops early if yield returns false. For a /64 Prefix, the only
+// candidate is the Prefix itself.
+//
+// allocated is copied into a set before FreeSubnets returns, so later changes
+// to allocated do not affect the iterator.
+func (p *Prefix) FreeSubnets(allocated []uint16) func(yield func(*Prefix) bool) {
+	used := make(map[uint16]struct{}, len(allocated))
+	for _, id := range allocated {
+		used[id] = struct{}{}
+	}
+
+	// Determine the range of subnet IDs delegated to this Prefix.
+	var first, last uint16
+	switch ones, _ := p.ipMask().Size(); ones {
+	case 48:
+		first, last = 0, 0xffff
+	case 56:
+		first, last = p.SubnetID&0xff00, p.SubnetID|0x00ff
+	default:
+		first, last = p.SubnetID, p.SubnetID
+	}
+
+	pp := *p
+	pp.mask = net.CIDRMask(64, 128)
+
+	return func(yield func(*Prefix) bool) {
+		for id := uint32(first); id <= uint32(last); id++ {
+			if _, ok := used[uint16(id)]; ok {
+				continue
+			}
+
+			sub := pp
+			sub.SubnetID = uint16(id)
+			if !yield(&sub) {
+				return
+			}
+		}
+	}
+}
+
 // String returns the CIDR notation string for a Prefix.
 func (p *Prefix) String() string { return p.IPNet().String() }
 
-// Parse parses a /48 or /64 Prefix from a CIDR notation string. If s is not a
-// /48 or /64 IPv6 Unique Local Address prefix, it returns an error.
+// StringExpanded returns the CIDR notation string for a Prefix with all eight
+// hextets of the address fully zero-padded and no zero compression, such as
+// "fd00:0000:0000:0000:0000:0000:0000:0000/48". This form is useful for
+// aligning columns or comparing against devices which emit expanded addresses.
+func (p *Prefix) StringExpanded() string {
+	ipn := p.IPNet()
+	ones, _ := ipn.Mask.Size()
+
+	var sb strings.Builder
+	for i := 0; i < net.IPv6len; i += 2 {
+		if i > 0 {
+			sb.WriteByte(':')
+		}
+
+		fmt.Fprintf(&sb, "%04x", binary.BigEndian.Uint16(ipn.IP[i:i+2]))
+	}
+
+	fmt.Fprintf(&sb, "/%d", ones)
+	return sb.String()
+}
+
+// Describe returns a human-readable description of the fields of a Prefix, as
+// printed by cmd/rfc4193, such as:
+//
+//	local: true, global ID: 0x5a5c390fc1, subnet ID: 0x0000, prefix: /48
+func Describe(p *Prefix) string {
+	local, globalID, subnetID, prefixLen := Format(p)
+	return fmt.Sprintf("local: %v, global ID: %s, subnet ID: %s, prefix: /%d",
+		local, globalID, subnetID, prefixLen)
+}
+
+// Format returns the components of a Prefix exactly as they are printed by
+// Describe and cmd/rfc4193: the global ID as 10 hexadecimal digits and the
+// subnet ID as 4 hexadecimal digits, each with a "0x" prefix, such as
+// "0x5a5c390fc1" and "0x0000", and the prefix length in bits, such as 48.
+func Format(p *Prefix) (local bool, globalID, subnetID string, prefixLen int) {
+	prefixLen, _ = p.ipMask().Size()
+	return p.Local, fmt.Sprintf("%#0x", p.GlobalID), fmt.Sprintf("%#04x", p.SubnetID), prefixLen
+}
+
+// Parse parses a /48, /56, or /64 Prefix from a CIDR notation string. If s is
+// not a /48, /56, or /64 IPv6 Unique Local Address prefix, it returns an error.
 func Parse(s string) (*Prefix, error) {
 	ip, cidr, err := net.ParseCIDR(s)
 	if err != nil {
 		return nil, err
 	}
 
-	// Only accept IPv6 ULA /48 or /64 prefixes.
+	// Only accept IPv6 ULA /48, /56, or /64 prefixes.
 	if ip.To16() == nil || ip.To4() != nil {
 		return nil, fmt.Errorf("rfc4193: invalid IPv6 address: %s", s)
 	}
 
 	ones, _ := cidr.Mask.Size()
-	if !cidr.IP.Equal(ip) || !ula.Contains(ip) || (ones != 48 && ones != 64) {
-		return nil, fmt.Errorf("rfc4193: must specify a Unique Local Address /48 or /64 IPv6 prefix: %s", s)
+	if !cidr.IP.Equal(ip) || !ula.Contains(ip) || (ones != 48 && ones != 56 && ones != 64) {
+		return nil, fmt.Errorf("rfc4193: must specify a Unique Local Address /48, /56, or /64 IPv6 prefix: %s", s)
+	}
+
+	return newPrefix(ip, ones), nil
+}
+
+// NetipPrefix produces a netip.Prefix value from a Prefix.
+func (p *Prefix) NetipPrefix() netip.Prefix {
+	ones, _ := p.ipMask().Size()
+	return netip.PrefixFrom(p.Addr(), ones)
+}
+
+// ContainsAddr reports whether the Prefix contains a, respecting the length of
+// the Prefix: a /48 Prefix contains every address in all of its /64 subnets,
+// while a /64 Prefix contains only its own addresses. The zero netip.Addr and
+// IPv4 addresses, including IPv4-mapped IPv6 addresses, are never contained.
+// As with netip.Prefix.Contains, an address with an IPv6 zone is never
+// contained.
+func (p *Prefix) ContainsAddr(a netip.Addr) bool {
+	return p.NetipPrefix().Contains(a)
+}
+
+// OverlapsIPNet reports whether the Prefix and n share any addresses, such as
+// when checking a Prefix against an existing address plan stored as
+// *net.IPNet values. n may be any IPv6 network: one which is not within the
+// Unique Local Address range fc00::/7 never overlaps a Prefix, while one which
+// contains the Prefix, such as fc00::/7 itself, always does. A nil n, an IPv4
+// or IPv4-mapped IPv6 network, or an n with a non-canonical mask never
+// overlaps.
+func (p *Prefix) OverlapsIPNet(n *net.IPNet) bool {
+	if n == nil || len(n.IP) != net.IPv6len || n.IP.To4() != nil {
+		return false
 	}
 
+	ones, bits := n.Mask.Size()
+	if bits != 8*net.IPv6len {
+		return false
+	}
+
+	addr, _ := netip.AddrFromSlice(n.IP)
+	return p.NetipPrefix().Overlaps(netip.PrefixFrom(addr, ones).Masked())
+}
+
+// FromNetipPrefix produces a Prefix from a netip.Prefix. As with Parse, if
+// prefix is not a /48, /56, or /64 IPv6 Unique Local Address prefix, it
+// returns an error.
+func FromNetipPrefix(prefix netip.Prefix) (*Prefix, error) {
+	addr, ones := prefix.Addr(), prefix.Bits()
+	if !prefix.IsValid() || !addr.Is6() || addr.Is4In6() {
+		return nil, fmt.Errorf("rfc4193: invalid IPv6 address: %s", prefix)
+	}
+
+	ip := addr.As16()
+	if prefix != prefix.Masked() || !ula.Contains(ip[:]) || (ones != 48 && ones != 56 && ones != 64) {
+		return nil, fmt.Errorf("rfc4193: must specify a Unique Local Address /48, /56, or /64 IPv6 prefix: %s", prefix)
+	}
+
+	return newPrefix(ip[:], ones), nil
+}
+
+// newPrefix produces a Prefix from a validated 16 byte IPv6 address and prefix
+// length.
+func newPrefix(ip []byte, ones int) *Prefix {
 	p := Prefix{
 		Local:    ip[0]&0x01 == 1,
 		SubnetID: binary.BigEndian.Uint16(ip[6:8]),
@@ -111,7 +507,7 @@ func Parse(s string) (*Prefix, error) {
 	}
 	copy(p.GlobalID[:], ip[1:6])
 
-	return &p, nil
+	return &p
 }
 
 // Generate produces a /48 Prefix by using mac (typically the MAC address of a
@@ -122,26 +518,201 @@ func Parse(s string) (*Prefix, error) {
 // cryptographically-secure random bytes will be used as a seed.
 func Generate(mac net.HardwareAddr) (*Prefix, error) {
 	// Generate a Prefix using real timestamps and crypto/rand.Reader.
-	return (&generator{
-		now: time.Now,
-		cr:  rand.Reader,
-	}).generate(mac)
+	return (&Generator{}).Generate(mac)
 }
 
-// A generator backs the logic for Generate. Its fields can be modified to
-// generate deterministic output for tests.
-type generator struct {
-	now func() time.Time
-	cr  io.Reader
+// FromIPv4 deterministically produces a /48 Prefix for an IPv4 address, such
+// as the network address of an existing IPv4 site, so that the same ULA prefix
+// is always produced for that site during a dual-stack rollout.
+//
+// FromIPv4 does not use the algorithm specified in RFC 4193, section 3.2.2:
+// the global ID is derived from a hash of the IPv4 address alone. As a result,
+// the Prefix is only as unique as the IPv4 address, so it is not suitable for
+// IPv4 addresses which are reused across sites, such as RFC 1918 addresses
+// shared by multiple organizations. Use Generate where possible.
+func FromIPv4(ipv4 net.IP) (*Prefix, error) {
+	ip4 := ipv4.To4()
+	if ip4 == nil {
+		return nil, fmt.Errorf("rfc4193: invalid IPv4 address: %s", ipv4)
+	}
+
+	// Prefix the input to separate it from other uses of SHA-1, and use the
+	// least significant 40 bits as the Global ID as Generate does.
+	out := sha1.Sum(append([]byte("rfc4193.FromIPv4:"), ip4...))
+
+	p := &Prefix{
+		Local: true,
+		mask:  net.CIDRMask(48, 128),
+	}
+	copy(p.GlobalID[:], out[15:])
+
+	return p, nil
+}
+
+// GlobalID40 returns the global ID of a Prefix as a right-aligned 40-bit
+// integer, such as for storage in a database or ULA registry.
+func (p *Prefix) GlobalID40() uint64 {
+	var b [8]byte
+	copy(b[3:], p.GlobalID[:])
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// maxGlobalID40 is the largest global ID which fits in 40 bits.
+const maxGlobalID40 = 1<<40 - 1
+
+// NewPrefixFromGlobalID40 produces a /48 Prefix from a global ID in the form
+// returned by GlobalID40. If id does not fit in 40 bits, it returns an error.
+func NewPrefixFromGlobalID40(id uint64, local bool) (*Prefix, error) {
+	if id > maxGlobalID40 {
+		return nil, fmt.Errorf("rfc4193: global ID %#x exceeds 40 bits", id)
+	}
+
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+
+	p := &Prefix{
+		Local: local,
+		mask:  net.CIDRMask(48, 128),
+	}
+	copy(p.GlobalID[:], b[3:])
+
+	return p, nil
 }
 
-// generate generates a Prefix using the configured generator and seed.
-func (g *generator) generate(seed net.HardwareAddr) (*Prefix, error) {
+// maxRetries is the number of times GenerateN will retry generating a Prefix
+// after a duplicate or all-zero global ID before giving up.
+const maxRetries = 32
+
+// GenerateN produces n distinct /48 Prefixes, each with a unique and non-zero
+// global ID. Cryptographically-secure random bytes are used as the seed for
+// each Prefix, as with Generate(nil).
+//
+// Collisions are retried a bounded number of times, after which an error is
+// returned; in practice this only occurs if the random source is broken.
+func GenerateN(n int) ([]*Prefix, error) { return (&Generator{}).GenerateN(n) }
+
+// CollisionProbability returns the approximate probability that any two of n
+// randomly generated /48 Prefixes share the same 40-bit global ID, using the
+// birthday problem approximation 1 - e^(-n(n-1) / 2^41).
+//
+// For example, the probability of a collision between 1 million Prefixes is
+// approximately 0.37.
+func CollisionProbability(n int) float64 {
+	if n < 2 {
+		return 0
+	}
+
+	// Use Expm1 for precision when the probability is very small.
+	const ids = 1 << 40
+	fn := float64(n)
+	return -math.Expm1(-fn * (fn - 1) / (2 * ids))
+}
+
+// A Generator generates Prefixes using the algorithm specified in RFC 4193,
+// section 3.2.2. The zero value is ready to use and is equivalent to the
+// package-level Generate and GenerateN functions. Its fields can be set to
+// produce reproducible output, such as in tests.
+type Generator struct {
+	// Now returns the time of day used as input to the algorithm. If nil,
+	// time.Now is used.
+	Now func() time.Time
+
+	// Rand is read to produce a node-specific identifier when no MAC address
+	// seed is specified. If nil, crypto/rand.Reader is used.
+	Rand io.Reader
+
+	// UseNTPTime encodes the time of day in 64-bit NTP format using NTPTime,
+	// as RFC 4193 specifies. By default, the time of day is instead encoded
+	// as the number of nanoseconds since the Unix epoch, so that Generators
+	// configured as in earlier versions of this package continue to produce
+	// the same Prefixes. Setting UseNTPTime changes the Prefix produced for a
+	// given time and seed.
+	UseNTPTime bool
+}
+
+// GenerateN produces n distinct /48 Prefixes using the configured Generator.
+// See the package-level GenerateN function for details.
+func (g *Generator) GenerateN(n int) ([]*Prefix, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("rfc4193: invalid number of prefixes: %d", n)
+	}
+
+	var (
+		ps      = make([]*Prefix, 0, n)
+		seen    = make(map[[5]byte]struct{}, n)
+		retries int
+	)
+
+	for len(ps) < n {
+		p, err := g.Generate(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := seen[p.GlobalID]; ok || p.GlobalID == [5]byte{} {
+			retries++
+			if retries > maxRetries {
+				return nil, fmt.Errorf("rfc4193: failed to generate %d unique prefixes after %d retries", n, maxRetries)
+			}
+
+			continue
+		}
+
+		seen[p.GlobalID] = struct{}{}
+		ps = append(ps, p)
+	}
+
+	return ps, nil
+}
+
+// Generate produces a /48 Prefix from a MAC address seed using the configured
+// Generator. See the package-level Generate function for details.
+func (g *Generator) Generate(seed net.HardwareAddr) (*Prefix, error) {
+	p, _, err := g.GenerateDetailed(seed)
+	return p, err
+}
+
+// GenDetails contains the inputs used to generate a Prefix, so that the
+// derivation of the Prefix can be audited.
+type GenDetails struct {
+	// Identifier is the EUI-64 derived from the MAC address seed, or the
+	// random identifier used if no seed was specified.
+	Identifier [8]byte
+
+	// Time is the time of day used as input to the algorithm.
+	Time time.Time
+}
+
+// GenerateDetailed is like Generate, but also returns the inputs which were
+// hashed to produce the Prefix.
+func GenerateDetailed(mac net.HardwareAddr) (*Prefix, GenDetails, error) {
+	return (&Generator{}).GenerateDetailed(mac)
+}
+
+// GenerateDetailed is like Generate, but also returns the inputs which were
+// hashed to produce the Prefix.
+func (g *Generator) GenerateDetailed(seed net.HardwareAddr) (*Prefix, GenDetails, error) {
+	now, cr := g.Now, g.Rand
+	if now == nil {
+		now = time.Now
+	}
+	if cr == nil {
+		cr = rand.Reader
+	}
+
 	// Store a timestamp and 8-byte value for hash input.
 	in := make([]byte, 16)
 
 	// "1) Obtain the current time of day in 64-bit NTP format [NTP]."
-	binary.BigEndian.PutUint64(in[:8], uint64(g.now().UnixNano()))
+	//
+	// Earlier versions of this package used Unix nanoseconds instead, which
+	// remains the default for compatibility.
+	t := now()
+	ts := uint64(t.UnixNano())
+	if g.UseNTPTime {
+		ts = NTPTime(t)
+	}
+	binary.BigEndian.PutUint64(in[:8], ts)
 
 	// Produce an 8-byte value:
 	//
@@ -168,11 +739,11 @@ func (g *generator) generate(seed net.HardwareAddr) (*Prefix, error) {
 	case seed == nil:
 		// No seed; so we will use an io.Reader (usually crypto/rand.Reader) to
 		// produce the "suitably unique identifier".
-		if _, err := io.ReadFull(g.cr, in[8:]); err != nil {
-			return nil, err
+		if _, err := io.ReadFull(cr, in[8:]); err != nil {
+			return nil, GenDetails{}, err
 		}
 	default:
-		return nil, errors.New("rfc4193: expected an EUI-48 format MAC address or nil MAC address")
+		return nil, GenDetails{}, errors.New("rfc4193: expected an EUI-48 format MAC address or nil MAC address")
 	}
 
 	// Always produce a /48 with the local flag set, per the
@@ -189,5 +760,57 @@ func (g *generator) generate(seed net.HardwareAddr) (*Prefix, error) {
 	out := sha1.Sum(in)
 	copy(p.GlobalID[:], out[15:])
 
-	return p, nil
+	d := GenDetails{Time: t}
+	copy(d.Identifier[:], in[8:])
+
+	return p, d, nil
+}
+
+// ntpEpochOffset is the number of seconds between the NTP epoch, 1900-01-01,
+// and the Unix epoch, 1970-01-01.
+const ntpEpochOffset = 2208988800
+
+// NTPTime converts t to the 64-bit NTP timestamp format described in RFC 5905,
+// section 6: the upper 32 bits are the number of seconds since 1900-01-01
+// 00:00:00 UTC, and the lower 32 bits are the fraction of a second. This is the
+// format of the time of day used as input to the algorithm in RFC 4193,
+// section 3.2.2, when Generator.UseNTPTime is set.
+//
+// As in NTP, the seconds wrap modulo 2^32, so times on or after 2036-02-07
+// 06:28:16 UTC, the start of NTP era 1, wrap around to small values.
+func NTPTime(t time.Time) uint64 {
+	var (
+		secs = uint64(t.Unix()+ntpEpochOffset) & 0xffffffff
+		frac = (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+	)
+
+	return secs<<32 | frac
+}
+
+var _ flag.Value = &PrefixValue{}
+
+// A PrefixValue is a flag.Value which parses a Prefix using Parse, for use
+// with flag.Var. Prefix is nil until Set succeeds.
+type PrefixValue struct {
+	Prefix *Prefix
+}
+
+// String implements flag.Value.
+func (v *PrefixValue) String() string {
+	if v == nil || v.Prefix == nil {
+		return ""
+	}
+
+	return v.Prefix.String()
+}
+
+// Set implements flag.Value.
+func (v *PrefixValue) Set(s string) error {
+	p, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	v.Prefix = p
+	return nil
 }
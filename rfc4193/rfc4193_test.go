@@ -3,11 +3,19 @@ package rfc4193
 import (
 	"bytes"
 	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
 	"net"
+	"net/netip"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 var (
@@ -75,6 +83,7 @@ func TestGenerateDeterministic(t *testing.T) {
 	tests := []struct {
 		name string
 		seed net.HardwareAddr
+		ntp  bool
 		ok   bool
 		p    *Prefix
 		ipn  *net.IPNet
@@ -110,20 +119,1673 @@ func TestGenerateDeterministic(t *testing.T) {
 				Mask: p48,
 			},
 		},
+		{
+			name: "OK seed NTP",
+			seed: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			ntp:  true,
+			ok:   true,
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0xf3, 0x57, 0x36, 0xdd, 0x2d},
+				mask:     p48,
+			},
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fdf3:5736:dd2d::"),
+				Mask: p48,
+			},
+		},
+		{
+			name: "nil seed NTP",
+			ntp:  true,
+			ok:   true,
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0x87, 0xad, 0xd5, 0xa0, 0x8b},
+				mask:     p48,
+			},
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd87:add5:a08b::"),
+				Mask: p48,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set up g for deterministic output with a fixed timestamp and
+			// reader bytes.
+			g := &Generator{
+				Now:        func() time.Time { return time.Unix(1, 0) },
+				Rand:       bytes.NewReader(make([]byte, 8)),
+				UseNTPTime: tt.ntp,
+			}
+
+			p, err := g.Generate(tt.seed)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to generate prefix: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				return
+			}
+
+			testPrefixes(t, tt.p, p, tt.ipn)
+		})
+	}
+}
+
+func TestNTPTime(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		ts   uint64
+	}{
+		{
+			name: "NTP epoch",
+			t:    time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Unix epoch",
+			t:    time.Unix(0, 0),
+			ts:   0x83aa7e80_00000000,
+		},
+		{
+			name: "half second",
+			t:    time.Unix(1, int64(500*time.Millisecond)),
+			ts:   0x83aa7e81_80000000,
+		},
+		{
+			name: "time zone",
+			t:    time.Unix(1, 0).In(time.FixedZone("test", 3600)),
+			ts:   0x83aa7e81_00000000,
+		},
+		{
+			name: "end of era 0",
+			t:    time.Date(2036, time.February, 7, 6, 28, 15, 999999999, time.UTC),
+			ts:   0xffffffff_fffffffb,
+		},
+		{
+			name: "start of era 1",
+			t:    time.Date(2036, time.February, 7, 6, 28, 16, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ts, NTPTime(tt.t)); diff != "" {
+				t.Fatalf("unexpected NTP timestamp (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateDetailed(t *testing.T) {
+	tests := []struct {
+		name string
+		seed net.HardwareAddr
+		d    GenDetails
+	}{
+		{
+			name: "EUI-48 seed",
+			seed: net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+			d: GenDetails{
+				Identifier: [8]byte{0x02, 0x12, 0x7f, 0xff, 0xfe, 0xeb, 0x6b, 0x40},
+				Time:       time.Unix(1, 0),
+			},
+		},
+		{
+			name: "nil seed",
+			d: GenDetails{
+				Identifier: [8]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+				Time:       time.Unix(1, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newG := func() *Generator {
+				return &Generator{
+					Now:  func() time.Time { return time.Unix(1, 0) },
+					Rand: bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8}),
+				}
+			}
+
+			p, d, err := newG().GenerateDetailed(tt.seed)
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.d, d); diff != "" {
+				t.Fatalf("unexpected details (-want +got):\n%s", diff)
+			}
+
+			// The details reproduce the same Prefix as Generate.
+			want, err := newG().Generate(tt.seed)
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+
+			if diff := cmp.Diff(want, p, cmp.AllowUnexported(Prefix{})); diff != "" {
+				t.Fatalf("unexpected Prefix (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateN(t *testing.T) {
+	for _, n := range []int{0, 1, 100} {
+		t.Run(fmt.Sprintf("%d", n), func(t *testing.T) {
+			ps, err := GenerateN(n)
+			if err != nil {
+				t.Fatalf("failed to generate prefixes: %v", err)
+			}
+
+			if diff := cmp.Diff(n, len(ps)); diff != "" {
+				t.Fatalf("unexpected number of prefixes (-want +got):\n%s", diff)
+			}
+
+			seen := make(map[[5]byte]bool)
+			for _, p := range ps {
+				if p.GlobalID == [5]byte{} {
+					t.Fatal("global ID for prefix was not set")
+				}
+				if seen[p.GlobalID] {
+					t.Fatalf("duplicate global ID: %#x", p.GlobalID)
+				}
+
+				seen[p.GlobalID] = true
+			}
+		})
+	}
+}
+
+func TestGenerateNErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{
+			name: "negative",
+			n:    -1,
+		},
+		{
+			// A fixed time and reader always produce the same global ID, so
+			// the retries must eventually be exhausted.
+			name: "collisions",
+			n:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{
+				Now:  func() time.Time { return time.Unix(1, 0) },
+				Rand: bytes.NewReader(make([]byte, 8*(maxRetries+2))),
+			}
+
+			if _, err := g.GenerateN(tt.n); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
+
+func TestCollisionProbability(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		p    float64
+	}{
+		{
+			name: "negative",
+			n:    -1,
+		},
+		{
+			name: "zero",
+		},
+		{
+			name: "one",
+			n:    1,
+		},
+		{
+			name: "two",
+			n:    2,
+			p:    1.0 / (1 << 40),
+		},
+		{
+			name: "three",
+			n:    3,
+			p:    3.0 / (1 << 40),
+		},
+		{
+			name: "2^20",
+			n:    1 << 20,
+			p:    1 - math.Exp(-(1<<40-1<<20)/float64(1<<41)),
+		},
+		{
+			name: "2^24",
+			n:    1 << 24,
+			p:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.p, CollisionProbability(tt.n), cmpopts.EquateApprox(1e-9, 0)); diff != "" {
+				t.Fatalf("unexpected probability (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFromIPv4(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   net.IP
+		s    string
+		ok   bool
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "IPv6",
+			ip:   net.ParseIP("2001:db8::1"),
+		},
+		{
+			name: "OK",
+			ip:   net.IPv4(192, 0, 2, 0),
+			s:    "fd0c:18:1c05::/48",
+			ok:   true,
+		},
+		{
+			name: "OK 4 byte",
+			ip:   net.IP{198, 51, 100, 0},
+			s:    "fd21:c456:37f3::/48",
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := FromIPv4(tt.ip)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to produce prefix: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tt.s, p.String()); diff != "" {
+				t.Fatalf("unexpected prefix (-want +got):\n%s", diff)
+			}
+
+			// The same input always produces the same Prefix.
+			pp, err := FromIPv4(tt.ip)
+			if err != nil {
+				t.Fatalf("failed to produce prefix: %v", err)
+			}
+
+			if diff := cmp.Diff(p, pp, cmp.AllowUnexported(Prefix{})); diff != "" {
+				t.Fatalf("unexpected second prefix (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGlobalID40(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    uint64
+		local bool
+		s     string
+		ok    bool
+	}{
+		{
+			name: "too large",
+			id:   1 << 40,
+		},
+		{
+			name: "zero",
+			s:    "fc00::/48",
+			ok:   true,
+		},
+		{
+			name:  "OK",
+			id:    0x5a5c390fc1,
+			local: true,
+			s:     "fd5a:5c39:fc1::/48",
+			ok:    true,
+		},
+		{
+			name:  "maximum",
+			id:    1<<40 - 1,
+			local: true,
+			s:     "fdff:ffff:ffff::/48",
+			ok:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPrefixFromGlobalID40(tt.id, tt.local)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to produce prefix: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tt.s, p.String()); diff != "" {
+				t.Fatalf("unexpected prefix (-want +got):\n%s", diff)
+			}
+
+			// The global ID round-trips through its parsed form.
+			if diff := cmp.Diff(tt.id, mustParse(tt.s).GlobalID40()); diff != "" {
+				t.Fatalf("unexpected global ID (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixManual(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Prefix
+		ipn  *net.IPNet
+	}{
+		{
+			name: "local false /48",
+			p: &Prefix{
+				GlobalID: [5]byte{0: 0x01},
+			},
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fc01::"),
+				Mask: p48,
+			},
+		},
+		{
+			name: "local true /48",
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0: 0x02},
+			},
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd02::"),
+				Mask: p48,
+			},
+		},
+		{
+			name: "local false /64",
+			p: &Prefix{
+				GlobalID: [5]byte{0: 0x03},
+				SubnetID: 0x1010,
+			},
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fc03:0:0:1010::"),
+				Mask: p64,
+			},
+		},
+		{
+			name: "local true /64",
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0: 0x04},
+				SubnetID: 0x2020,
+			},
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd04:0:0:2020::"),
+				Mask: p64,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ipn, tt.p.IPNet()); diff != "" {
+				t.Fatalf("unexpected Prefix.IPNet (-want +got):\n%s", diff)
+			}
+
+			// Child subnet with a matching subnet ID should always reside
+			// within (or be equal to for /64) their parent.
+			child := tt.p.Subnet(tt.p.SubnetID).IPNet()
+			if !tt.ipn.Contains(child.IP) {
+				t.Fatalf("parent prefix %q does not contain child prefix %q", tt.ipn, child)
+			}
+
+			// For /64s exclusively, a different subnet ID produces a
+			// non-overlapping sibling /64 prefix.
+			if ones, _ := tt.ipn.Mask.Size(); ones != 48 {
+				sibling := tt.p.Subnet(tt.p.SubnetID + 1).IPNet()
+				if child.Contains(sibling.IP) {
+					t.Fatalf("child prefix %q contains sibling prefix %q", child, sibling)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPrefix(t *testing.T) {
+	id := [5]byte{0x5a, 0x5c, 0x39, 0x0f, 0xc1}
+
+	tests := []struct {
+		name string
+		p    *Prefix
+		s    string
+	}{
+		{
+			name: "literal zero subnet",
+			p:    &Prefix{Local: true, GlobalID: id},
+			s:    "fd5a:5c39:fc1::/48",
+		},
+		{
+			name: "literal non-zero subnet",
+			p:    &Prefix{Local: true, GlobalID: id, SubnetID: 0x10},
+			s:    "fd5a:5c39:fc1:10::/64",
+		},
+		{
+			name: "/48",
+			p:    NewPrefix48(true, id),
+			s:    "fd5a:5c39:fc1::/48",
+		},
+		{
+			name: "/48 not local",
+			p:    NewPrefix48(false, id),
+			s:    "fc5a:5c39:fc1::/48",
+		},
+		{
+			name: "/64 zero subnet",
+			p:    NewPrefix64(true, id, 0),
+			s:    "fd5a:5c39:fc1::/64",
+		},
+		{
+			name: "/64",
+			p:    NewPrefix64(true, id, 0x10),
+			s:    "fd5a:5c39:fc1:10::/64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.s, tt.p.String()); diff != "" {
+				t.Fatalf("unexpected prefix (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixSubnet(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent string
+		id     uint16
+		ipn    *net.IPNet
+		ok     bool
+	}{
+		{
+			name:   "/48 first",
+			parent: "fd00::/48",
+			id:     0x0000,
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd00::"),
+				Mask: p64,
+			},
+			ok: true,
+		},
+		{
+			name:   "/48 last",
+			parent: "fd00::/48",
+			id:     0xffff,
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd00:0:0:ffff::"),
+				Mask: p64,
+			},
+			ok: true,
+		},
+		{
+			name:   "/56 first",
+			parent: "fd00:0:0:1200::/56",
+			id:     0x00,
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd00:0:0:1200::"),
+				Mask: p64,
+			},
+			ok: true,
+		},
+		{
+			name:   "/56 last",
+			parent: "fd00:0:0:1200::/56",
+			id:     0xff,
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd00:0:0:12ff::"),
+				Mask: p64,
+			},
+			ok: true,
+		},
+		{
+			// Subnet ignores the high bits, but SubnetChecked rejects them.
+			name:   "/56 out of range",
+			parent: "fd00:0:0:1200::/56",
+			id:     0x1ff,
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd00:0:0:12ff::"),
+				Mask: p64,
+			},
+		},
+		{
+			name:   "/64 sibling",
+			parent: "fd00:0:0:1234::/64",
+			id:     0xabcd,
+			ipn: &net.IPNet{
+				IP:   net.ParseIP("fd00:0:0:abcd::"),
+				Mask: p64,
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.parent)
+			if err != nil {
+				t.Fatalf("failed to parse parent: %v", err)
+			}
+
+			checked, err := p.SubnetChecked(tt.id)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to produce checked subnet: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			sub := p.Subnet(tt.id)
+			if diff := cmp.Diff(tt.ipn, sub.IPNet()); diff != "" {
+				t.Fatalf("unexpected subnet (-want +got):\n%s", diff)
+			}
+			if tt.ok {
+				if diff := cmp.Diff(sub, checked, cmp.AllowUnexported(Prefix{})); diff != "" {
+					t.Fatalf("unexpected checked subnet (-want +got):\n%s", diff)
+				}
+			}
+
+			// The global ID must never be disturbed by subnetting.
+			if diff := cmp.Diff(p.GlobalID, sub.GlobalID); diff != "" {
+				t.Fatalf("unexpected global ID (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent string
+		n      int
+		ips    []string
+		ok     bool
+	}{
+		{
+			name:   "/56",
+			parent: "fd00:0:0:1200::/56",
+			n:      1,
+		},
+		{
+			name:   "/64",
+			parent: "fd00:0:0:1234::/64",
+			n:      1,
+		},
+		{
+			name:   "negative",
+			parent: "fd00::/48",
+			n:      -1,
+		},
+		{
+			name:   "too many",
+			parent: "fd00::/48",
+			n:      65537,
+		},
+		{
+			name:   "none",
+			parent: "fd00::/48",
+			ips:    []string{},
+			ok:     true,
+		},
+		{
+			name:   "OK",
+			parent: "fd00::/48",
+			n:      3,
+			ips: []string{
+				"fd00::/64",
+				"fd00:0:0:1::/64",
+				"fd00:0:0:2::/64",
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.parent)
+			if err != nil {
+				t.Fatalf("failed to parse parent: %v", err)
+			}
+
+			ps, err := p.Split(tt.n)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to split prefix: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				return
+			}
+
+			ips := make([]string, 0, len(ps))
+			for _, p := range ps {
+				ips = append(ips, p.String())
+			}
+
+			if diff := cmp.Diff(tt.ips, ips); diff != "" {
+				t.Fatalf("unexpected prefixes (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// All 65536 /64s are available, ending at the final subnet ID.
+	p, err := Parse("fd00::/48")
+	if err != nil {
+		t.Fatalf("failed to parse prefix: %v", err)
+	}
+
+	ps, err := p.Split(65536)
+	if err != nil {
+		t.Fatalf("failed to split prefix: %v", err)
+	}
+
+	if diff := cmp.Diff("fd00:0:0:ffff::/64", ps[len(ps)-1].String()); diff != "" {
+		t.Fatalf("unexpected last prefix (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrefixAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Prefix
+		want netip.Addr
+	}{
+		{
+			name: "manual /48",
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+			},
+			want: netip.MustParseAddr("fd01:203:405::"),
+		},
+		{
+			name: "manual /64",
+			p: &Prefix{
+				GlobalID: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+				SubnetID: 0xabcd,
+			},
+			want: netip.MustParseAddr("fc01:203:405:abcd::"),
+		},
+		{
+			name: "parsed /56",
+			p:    mustParse("fd00:0:0:1200::/56"),
+			want: netip.MustParseAddr("fd00:0:0:1200::"),
+		},
+		{
+			name: "subnet",
+			p:    mustParse("fd00::/48").Subnet(1),
+			want: netip.MustParseAddr("fd00:0:0:1::"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.p.Addr()
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(x, y netip.Addr) bool {
+				return x == y
+			})); diff != "" {
+				t.Fatalf("unexpected address (-want +got):\n%s", diff)
+			}
+
+			// The address must agree with the *net.IPNet form.
+			if !tt.p.IPNet().IP.Equal(got.AsSlice()) {
+				t.Fatalf("address %s does not match IPNet %s", got, tt.p.IPNet())
+			}
+		})
+	}
+}
+
+func TestPrefixMask(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Prefix
+		ones int
+		lazy bool
+	}{
+		{
+			name: "literal /48",
+			p:    &Prefix{Local: true},
+			ones: 48,
+			lazy: true,
+		},
+		{
+			name: "literal /64",
+			p:    &Prefix{Local: true, SubnetID: 1},
+			ones: 64,
+			lazy: true,
+		},
+		{
+			name: "parsed /48",
+			p:    mustParse("fd00::/48"),
+			ones: 48,
+		},
+		{
+			name: "parsed /56",
+			p:    mustParse("fd00:0:0:1200::/56"),
+			ones: 56,
+		},
+		{
+			name: "NewPrefix64 zero subnet",
+			p:    NewPrefix64(true, [5]byte{}, 0),
+			ones: 64,
+		},
+		{
+			name: "subnet",
+			p:    mustParse("fd00::/48").Subnet(0),
+			ones: 64,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mask := tt.p.Mask()
+			ones, bits := mask.Size()
+			if diff := cmp.Diff([]int{tt.ones, 128}, []int{ones, bits}); diff != "" {
+				t.Fatalf("unexpected mask size (-want +got):\n%s", diff)
+			}
+
+			// Mask must not decide the length of a lazy Prefix.
+			if diff := cmp.Diff(tt.lazy, tt.p.mask == nil); diff != "" {
+				t.Fatalf("unexpected lazy state (-want +got):\n%s", diff)
+			}
+
+			// The returned mask is a copy.
+			for i := range mask {
+				mask[i] = 0
+			}
+
+			if diff := cmp.Diff(tt.ones, tt.p.NetipPrefix().Bits()); diff != "" {
+				t.Fatalf("unexpected prefix length (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixContainsAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Prefix
+		a    netip.Addr
+		ok   bool
+	}{
+		{
+			name: "zero",
+			p:    mustParse("fd00::/48"),
+		},
+		{
+			name: "IPv4",
+			p:    mustParse("fd00::/48"),
+			a:    netip.MustParseAddr("192.0.2.1"),
+		},
+		{
+			name: "IPv4-mapped",
+			p:    mustParse("fd00::/48"),
+			a:    netip.MustParseAddr("::ffff:192.0.2.1"),
+		},
+		{
+			name: "zone",
+			p:    mustParse("fd00::/48"),
+			a:    netip.MustParseAddr("fd00::1%eth0"),
+		},
+		{
+			name: "/48 network",
+			p:    mustParse("fd00::/48"),
+			a:    netip.MustParseAddr("fd00::"),
+			ok:   true,
+		},
+		{
+			name: "/48 subnet host",
+			p:    mustParse("fd00::/48"),
+			a:    netip.MustParseAddr("fd00:0:0:ffff::1"),
+			ok:   true,
+		},
+		{
+			name: "/48 other global ID",
+			p:    mustParse("fd00::/48"),
+			a:    netip.MustParseAddr("fd00:0:1::1"),
+		},
+		{
+			name: "/48 other local bit",
+			p:    mustParse("fd00::/48"),
+			a:    netip.MustParseAddr("fc00::1"),
+		},
+		{
+			name: "/64 host",
+			p:    mustParse("fd00:0:0:1::/64"),
+			a:    netip.MustParseAddr("fd00:0:0:1:ffff:ffff:ffff:ffff"),
+			ok:   true,
+		},
+		{
+			name: "/64 sibling subnet",
+			p:    mustParse("fd00:0:0:1::/64"),
+			a:    netip.MustParseAddr("fd00:0:0:2::1"),
+		},
+		{
+			name: "/64 parent network",
+			p:    mustParse("fd00:0:0:1::/64"),
+			a:    netip.MustParseAddr("fd00::1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ok, tt.p.ContainsAddr(tt.a)); diff != "" {
+				t.Fatalf("unexpected containment (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixOverlapsIPNet(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Prefix
+		n    *net.IPNet
+		ok   bool
+	}{
+		{
+			name: "nil",
+			p:    mustParse("fd00::/48"),
+		},
+		{
+			name: "IPv4",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("192.0.2.0/24"),
+		},
+		{
+			name: "IPv4 everything",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("0.0.0.0/0"),
+		},
+		{
+			name: "non-canonical mask",
+			p:    mustParse("fd00::/48"),
+			n: &net.IPNet{
+				IP:   net.ParseIP("fd00::"),
+				Mask: net.IPMask{15: 0xff},
+			},
+		},
+		{
+			name: "global",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("2001:db8::/32"),
+		},
+		{
+			name: "global unicast range",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("2000::/3"),
+		},
+		{
+			name: "link-local",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("fe80::/10"),
+		},
+		{
+			name: "IPv6 everything",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("::/0"),
+			ok:   true,
+		},
+		{
+			name: "ULA range",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("fc00::/7"),
+			ok:   true,
+		},
+		{
+			name: "local bit unset",
+			p:    mustParse("fd00::/48"),
+			n:    mustParseCIDR("fc00::/8"),
+		},
+		{
+			name: "equal",
+			p:    mustParse("fd00:1:2::/48"),
+			n:    mustParseCIDR("fd00:1:2::/48"),
+			ok:   true,
+		},
+		{
+			name: "/48 contains subnet",
+			p:    mustParse("fd00:1:2::/48"),
+			n:    mustParseCIDR("fd00:1:2:3::/64"),
+			ok:   true,
+		},
+		{
+			name: "/48 contains host",
+			p:    mustParse("fd00:1:2::/48"),
+			n:    mustParseCIDR("fd00:1:2:3::1/128"),
+			ok:   true,
+		},
+		{
+			name: "/48 other global ID",
+			p:    mustParse("fd00:1:2::/48"),
+			n:    mustParseCIDR("fd00:1:3::/48"),
+		},
+		{
+			name: "/64 within /56",
+			p:    mustParse("fd00:1:2:3::/64"),
+			n:    mustParseCIDR("fd00:1:2::/56"),
+			ok:   true,
+		},
+		{
+			name: "/64 sibling subnet",
+			p:    mustParse("fd00:1:2:3::/64"),
+			n:    mustParseCIDR("fd00:1:2:4::/64"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ok, tt.p.OverlapsIPNet(tt.n)); diff != "" {
+				t.Fatalf("unexpected overlap (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixHostAt(t *testing.T) {
+	tests := []struct {
+		name string
+		p    string
+		n    uint64
+		ip   net.IP
+		ok   bool
+	}{
+		{
+			name: "/48",
+			p:    "fd00::/48",
+			n:    1,
+		},
+		{
+			name: "/56",
+			p:    "fd00:0:0:1200::/56",
+			n:    1,
+		},
+		{
+			name: "zero",
+			p:    "fd00:0:0:1::/64",
+			ip:   net.ParseIP("fd00:0:0:1::"),
+			ok:   true,
+		},
+		{
+			name: "first",
+			p:    "fd00:0:0:1::/64",
+			n:    1,
+			ip:   net.ParseIP("fd00:0:0:1::1"),
+			ok:   true,
+		},
+		{
+			name: "large",
+			p:    "fd00:0:0:1::/64",
+			n:    0x0102030405060708,
+			ip:   net.ParseIP("fd00:0:0:1:102:304:506:708"),
+			ok:   true,
+		},
+		{
+			name: "last",
+			p:    "fd00:0:0:1::/64",
+			n:    1<<64 - 1,
+			ip:   net.ParseIP("fd00:0:0:1:ffff:ffff:ffff:ffff"),
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := mustParse(tt.p).HostAt(tt.n)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to produce host: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.ip, ip); diff != "" {
+				t.Fatalf("unexpected host (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		ok   bool
+	}{
+		{
+			name: "bad",
+			s:    "foo",
+		},
+		{
+			name: "IPv4",
+			s:    "192.0.2.0/24",
+		},
+		{
+			name: "individual IP",
+			s:    "fd00::1/64",
+		},
+		{
+			name: "IPv4-mapped IPv6",
+			s:    "::ffff:192.0.2.0/120",
+		},
+		{
+			name: "IPv4-mapped ULA-like",
+			s:    "::ffff:253.0.0.0/104",
+		},
+		{
+			name: "zone",
+			s:    "fd00::%eth0/48",
+		},
+		{
+			name: "/7",
+			s:    "fc00::/7",
+		},
+		{
+			name: "global unicast prefix",
+			s:    "2001:db8::/32",
+		},
+		{
+			name: "wrong subnet size",
+			s:    "2001:db8::/56",
+		},
+		{
+			name: "local false /48",
+			s:    "fc01::/48",
+			ok:   true,
+		},
+		{
+			name: "local true /48",
+			s:    "fd02::/48",
+			ok:   true,
+		},
+		{
+			name: "/56 host bits set",
+			s:    "fd00:0:0:1201::/56",
+		},
+		{
+			name: "local true /56",
+			s:    "fd02:0:0:1000::/56",
+			ok:   true,
+		},
+		{
+			name: "local false /64",
+			s:    "fc03:0:0:1010::/64",
+			ok:   true,
+		},
+		{
+			name: "local true /64",
+			s:    "fd04:0:0:2020::/64",
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.s)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				t.Logf("err: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.s, p.String()); diff != "" {
+				t.Fatalf("unexpected Prefix string (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewAllocatorErrors(t *testing.T) {
+	for _, s := range []string{"fd00:0:0:1200::/56", "fd00:0:0:1::/64"} {
+		t.Run(s, func(t *testing.T) {
+			if _, err := NewAllocator(mustParse(s)); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
+
+func TestAllocator(t *testing.T) {
+	a, err := NewAllocator(mustParse("fd00::/48"))
+	if err != nil {
+		t.Fatalf("failed to create allocator: %v", err)
+	}
+
+	reserve := func() string {
+		t.Helper()
+
+		p, err := a.Reserve()
+		if err != nil {
+			t.Fatalf("failed to reserve subnet: %v", err)
+		}
+
+		return p.String()
+	}
+
+	// Reserve the lowest free IDs, skipping any reserved explicitly.
+	if err := a.ReserveID(1); err != nil {
+		t.Fatalf("failed to reserve ID: %v", err)
+	}
+	if err := a.ReserveID(1); err == nil {
+		t.Fatal("expected an error reserving an ID twice, but none occurred")
+	}
+
+	got := []string{reserve(), reserve(), reserve()}
+	want := []string{"fd00::/64", "fd00:0:0:2::/64", "fd00:0:0:3::/64"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected subnets (-want +got):\n%s", diff)
+	}
+
+	// Released IDs are reserved again, lowest first.
+	a.Release(2)
+	a.Release(0)
+	a.Release(0)
+
+	got = []string{reserve(), reserve(), reserve()}
+	want = []string{"fd00::/64", "fd00:0:0:2::/64", "fd00:0:0:4::/64"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected subnets after release (-want +got):\n%s", diff)
+	}
+
+	if err := a.ReserveID(2); err == nil {
+		t.Fatal("expected an error reserving a reserved ID, but none occurred")
+	}
+	a.Release(2)
+	if err := a.ReserveID(2); err != nil {
+		t.Fatalf("failed to reserve released ID: %v", err)
+	}
+}
+
+func TestAllocatorExhaustion(t *testing.T) {
+	a, err := NewAllocator(mustParse("fd00::/48"))
+	if err != nil {
+		t.Fatalf("failed to create allocator: %v", err)
+	}
+
+	if err := a.ReserveID(0xffff); err != nil {
+		t.Fatalf("failed to reserve ID: %v", err)
+	}
+
+	// Reserve the remaining subnets concurrently, each exactly once.
+	const workers = 8
+	var (
+		mu   sync.Mutex
+		seen = make(map[uint16]bool)
+		wg   sync.WaitGroup
+	)
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for {
+				p, err := a.Reserve()
+				if err != nil {
+					return
+				}
+
+				mu.Lock()
+				if seen[p.SubnetID] {
+					panic(fmt.Sprintf("subnet %s reserved twice", p))
+				}
+				seen[p.SubnetID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if diff := cmp.Diff(1<<16-1, len(seen)); diff != "" {
+		t.Fatalf("unexpected number of subnets (-want +got):\n%s", diff)
+	}
+
+	if _, err := a.Reserve(); err == nil {
+		t.Fatal("expected an exhaustion error, but none occurred")
+	}
+
+	// Releasing any subnet makes exactly that subnet available again.
+	a.Release(0xffff)
+	p, err := a.Reserve()
+	if err != nil {
+		t.Fatalf("failed to reserve released subnet: %v", err)
+	}
+	if diff := cmp.Diff("fd00:0:0:ffff::/64", p.String()); diff != "" {
+		t.Fatalf("unexpected subnet (-want +got):\n%s", diff)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *Prefix
+		s    string
+		ok   bool
+	}{
+		{
+			name: "not /64",
+			a:    mustParse("fd00::/48"),
+			b:    mustParse("fd00:0:0:1::/64"),
+		},
+		{
+			name: "/56",
+			a:    mustParse("fd00:0:0:100::/56"),
+			b:    mustParse("fd00:0:0:1::/64"),
+		},
+		{
+			name: "different global ID",
+			a:    mustParse("fd00:0:1:1::/64"),
+			b:    mustParse("fd00:0:2:1::/64"),
+		},
+		{
+			name: "different local flag",
+			a:    mustParse("fd00:0:1:1::/64"),
+			b:    mustParse("fc00:0:1:1::/64"),
+		},
+		{
+			name: "same parent",
+			a:    mustParse("fd00:0:1:1::/64"),
+			b:    mustParse("fd00:0:1:ffff::/64"),
+			s:    "fd00:0:1::/48",
+			ok:   true,
+		},
+		{
+			name: "same subnet",
+			a:    mustParse("fd00:0:1:1::/64"),
+			b:    mustParse("fd00:0:1:1::/64"),
+			s:    "fd00:0:1::/48",
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := Aggregate(tt.a, tt.b)
+			if diff := cmp.Diff(tt.ok, ok); diff != "" {
+				t.Fatalf("unexpected aggregatability (-want +got):\n%s", diff)
+			}
+			if !ok {
+				return
+			}
+
+			if diff := cmp.Diff(tt.s, p.String()); diff != "" {
+				t.Fatalf("unexpected parent prefix (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"fd00::/48",
+		"fc00:0:1:1200::/56",
+		"fd00:0:0:1::/64",
+		"fd00::/8",
+		"::ffff:192.0.2.0/120",
+		"192.0.2.0/24",
+		"2001:db8::/48",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		p, err := Parse(s)
+		if err != nil {
+			return
+		}
+
+		// Any valid Prefix must round-trip through its string form.
+		pp, err := Parse(p.String())
+		if err != nil {
+			t.Fatalf("failed to parse %q from %q: %v", p, s, err)
+		}
+
+		if diff := cmp.Diff(p, pp, cmp.AllowUnexported(Prefix{})); diff != "" {
+			t.Fatalf("unexpected round-trip prefix for %q (-want +got):\n%s", s, diff)
+		}
+	})
+}
+
+func TestPrefixFreeSubnets(t *testing.T) {
+	tests := []struct {
+		name      string
+		p         *Prefix
+		allocated []uint16
+		limit     int
+		count     int
+		first     []string
+	}{
+		{
+			name:      "/48",
+			p:         mustParse("fd00::/48"),
+			allocated: []uint16{0, 1, 3, 1},
+			limit:     3,
+			count:     3,
+			first:     []string{"fd00:0:0:2::/64", "fd00:0:0:4::/64", "fd00:0:0:5::/64"},
+		},
+		{
+			name:      "/48 all",
+			p:         mustParse("fd00::/48"),
+			allocated: []uint16{0xffff},
+			count:     1<<16 - 1,
+			first:     []string{"fd00::/64", "fd00:0:0:1::/64"},
+		},
+		{
+			name:      "/56",
+			p:         mustParse("fd00:0:0:1200::/56"),
+			allocated: []uint16{0x1200, 0x1201, 0x1300},
+			count:     254,
+			first:     []string{"fd00:0:0:1202::/64", "fd00:0:0:1203::/64"},
+		},
+		{
+			name:  "/64 free",
+			p:     mustParse("fd00:0:0:1::/64"),
+			count: 1,
+			first: []string{"fd00:0:0:1::/64"},
+		},
+		{
+			name:      "/64 allocated",
+			p:         mustParse("fd00:0:0:1::/64"),
+			allocated: []uint16{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				count int
+				first []string
+			)
+
+			tt.p.FreeSubnets(tt.allocated)(func(p *Prefix) bool {
+				count++
+				if len(first) < len(tt.first) {
+					first = append(first, p.String())
+				}
+
+				return tt.limit == 0 || count < tt.limit
+			})
+
+			if diff := cmp.Diff(tt.count, count); diff != "" {
+				t.Fatalf("unexpected number of free subnets (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.first, first); diff != "" {
+				t.Fatalf("unexpected free subnets (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixHosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		limit int
+		n     int
+		first []string
+	}{
+		{
+			name:  "negative",
+			count: -1,
+		},
+		{
+			name:  "zero",
+			count: 0,
+		},
+		{
+			name:  "three",
+			count: 3,
+			n:     3,
+			first: []string{"fd00:0:0:1::", "fd00:0:0:1::1", "fd00:0:0:1::2"},
+		},
+		{
+			name:  "early break",
+			count: 10,
+			limit: 2,
+			n:     2,
+			first: []string{"fd00:0:0:1::", "fd00:0:0:1::1"},
+		},
+		{
+			name:  "capped",
+			count: 1 << 20,
+			n:     1 << 16,
+			first: []string{"fd00:0:0:1::"},
+		},
 	}
 
+	p := mustParse("fd00:0:0:1::/64")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Set up g for deterministic output with a fixed timestamp and
-			// reader bytes.
-			g := &generator{
-				now: func() time.Time { return time.Unix(1, 0) },
-				cr:  bytes.NewReader(make([]byte, 8)),
+			var (
+				n     int
+				first []string
+				last  net.IP
+			)
+
+			p.Hosts(tt.count)(func(ip net.IP) bool {
+				n++
+				if len(first) < len(tt.first) {
+					first = append(first, ip.String())
+				}
+				last = ip
+
+				return tt.limit == 0 || n < tt.limit
+			})
+
+			if diff := cmp.Diff(tt.n, n); diff != "" {
+				t.Fatalf("unexpected number of hosts (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.first, first); diff != "" {
+				t.Fatalf("unexpected hosts (-want +got):\n%s", diff)
+			}
+
+			// The final address must be HostAt(n-1), verifying that each
+			// yielded address is independent of the others.
+			if n > 0 {
+				want, err := p.HostAt(uint64(n - 1))
+				if err != nil {
+					t.Fatalf("failed to compute host: %v", err)
+				}
+				if !want.Equal(last) {
+					t.Fatalf("unexpected last host:\n- want: %v\n-  got: %v", want, last)
+				}
+			}
+		})
+	}
+}
+
+func TestPrefixHostsNot64(t *testing.T) {
+	for _, s := range []string{"fd00::/48", "fd00:0:0:1200::/56"} {
+		t.Run(s, func(t *testing.T) {
+			var n int
+			mustParse(s).Hosts(1)(func(net.IP) bool {
+				n++
+				return true
+			})
+
+			if diff := cmp.Diff(0, n); diff != "" {
+				t.Fatalf("unexpected number of hosts (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Prefix
+		s    string
+	}{
+		{
+			name: "generated /48",
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0x5a, 0x5c, 0x39, 0x0f, 0xc1},
+			},
+			s: "local: true, global ID: 0x5a5c390fc1, subnet ID: 0x0000, prefix: /48",
+		},
+		{
+			name: "parsed /56",
+			p:    mustParse("fc00:0:1:1200::/56"),
+			s:    "local: false, global ID: 0x0000000001, subnet ID: 0x1200, prefix: /56",
+		},
+		{
+			name: "subnet /64",
+			p:    mustParse("fd00::/48").Subnet(0xabcd),
+			s:    "local: true, global ID: 0x0000000000, subnet ID: 0xabcd, prefix: /64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.s, Describe(tt.p)); diff != "" {
+				t.Fatalf("unexpected description (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	type format struct {
+		Local              bool
+		GlobalID, SubnetID string
+		PrefixLen          int
+	}
+
+	tests := []struct {
+		name string
+		p    *Prefix
+		f    format
+	}{
+		{
+			name: "generated /48",
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0x5a, 0x5c, 0x39, 0x0f, 0xc1},
+			},
+			f: format{
+				Local:     true,
+				GlobalID:  "0x5a5c390fc1",
+				SubnetID:  "0x0000",
+				PrefixLen: 48,
+			},
+		},
+		{
+			name: "parsed /48 leading zeroes",
+			p:    mustParse("fc00:0:1::/48"),
+			f: format{
+				GlobalID:  "0x0000000001",
+				SubnetID:  "0x0000",
+				PrefixLen: 48,
+			},
+		},
+		{
+			name: "subnet /64",
+			p:    mustParse("fd00::/48").Subnet(0x0bcd),
+			f: format{
+				Local:     true,
+				GlobalID:  "0x0000000000",
+				SubnetID:  "0x0bcd",
+				PrefixLen: 64,
+			},
+		},
+		{
+			name: "parsed /64",
+			p:    mustParse("fdff:ffff:ffff:ffff::/64"),
+			f: format{
+				Local:     true,
+				GlobalID:  "0xffffffffff",
+				SubnetID:  "0xffff",
+				PrefixLen: 64,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f format
+			f.Local, f.GlobalID, f.SubnetID, f.PrefixLen = Format(tt.p)
+
+			if diff := cmp.Diff(tt.f, f); diff != "" {
+				t.Fatalf("unexpected format (-want +got):\n%s", diff)
 			}
+		})
+	}
+}
+
+func TestPrefixValue(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		s    string
+		ok   bool
+	}{
+		{
+			name: "unset",
+			ok:   true,
+		},
+		{
+			name: "bad",
+			args: []string{"-prefix", "2001:db8::/48"},
+		},
+		{
+			name: "OK",
+			args: []string{"-prefix", "fd00:0:0:1::/64"},
+			s:    "fd00:0:0:1::/64",
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v PrefixValue
 
-			p, err := g.generate(tt.seed)
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			fs.Var(&v, "prefix", "")
+
+			err := fs.Parse(tt.args)
 			if tt.ok && err != nil {
-				t.Fatalf("failed to generate prefix: %v", err)
+				t.Fatalf("failed to parse flags: %v", err)
 			}
 			if !tt.ok && err == nil {
 				t.Fatal("expected an error, but none occurred")
@@ -132,157 +1794,207 @@ func TestGenerateDeterministic(t *testing.T) {
 				return
 			}
 
-			testPrefixes(t, tt.p, p, tt.ipn)
+			if diff := cmp.Diff(tt.s, v.String()); diff != "" {
+				t.Fatalf("unexpected flag value (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
 
-func TestPrefixManual(t *testing.T) {
+func TestNetipPrefixRoundTrip(t *testing.T) {
 	tests := []struct {
 		name string
 		p    *Prefix
-		ipn  *net.IPNet
+		s    string
 	}{
 		{
-			name: "local false /48",
+			name: "generated /48",
 			p: &Prefix{
-				GlobalID: [5]byte{0: 0x01},
+				Local:    true,
+				GlobalID: [5]byte{0x5a, 0x5c, 0x39, 0x0f, 0xc1},
+				mask:     p48,
 			},
-			ipn: &net.IPNet{
-				IP:   net.ParseIP("fc01::"),
-				Mask: p48,
+			s: "fd5a:5c39:fc1::/48",
+		},
+		{
+			name: "non-local /48",
+			p: &Prefix{
+				GlobalID: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+				mask:     p48,
 			},
+			s: "fc01:203:405::/48",
 		},
 		{
-			name: "local true /48",
+			name: "/56",
 			p: &Prefix{
 				Local:    true,
-				GlobalID: [5]byte{0: 0x02},
-			},
-			ipn: &net.IPNet{
-				IP:   net.ParseIP("fd02::"),
-				Mask: p48,
+				GlobalID: [5]byte{0xff, 0xff, 0xff, 0xff, 0xff},
+				SubnetID: 0x1200,
+				mask:     net.CIDRMask(56, 128),
 			},
+			s: "fdff:ffff:ffff:1200::/56",
 		},
 		{
-			name: "local false /64",
+			name: "/64 first",
 			p: &Prefix{
-				GlobalID: [5]byte{0: 0x03},
-				SubnetID: 0x1010,
-			},
-			ipn: &net.IPNet{
-				IP:   net.ParseIP("fc03:0:0:1010::"),
-				Mask: p64,
+				Local:    true,
+				GlobalID: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+				mask:     p64,
 			},
+			s: "fd01:203:405::/64",
 		},
 		{
-			name: "local true /64",
+			name: "/64 subnet",
 			p: &Prefix{
 				Local:    true,
-				GlobalID: [5]byte{0: 0x04},
-				SubnetID: 0x2020,
+				GlobalID: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+				SubnetID: 0xabcd,
+				mask:     p64,
 			},
-			ipn: &net.IPNet{
-				IP:   net.ParseIP("fd04:0:0:2020::"),
-				Mask: p64,
+			s: "fd01:203:405:abcd::/64",
+		},
+		{
+			name: "non-local /64 last",
+			p: &Prefix{
+				GlobalID: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+				SubnetID: 0xffff,
+				mask:     p64,
 			},
+			s: "fc01:203:405:ffff::/64",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if diff := cmp.Diff(tt.ipn, tt.p.IPNet()); diff != "" {
-				t.Fatalf("unexpected Prefix.IPNet (-want +got):\n%s", diff)
+			np := tt.p.NetipPrefix()
+			if diff := cmp.Diff(tt.s, np.String()); diff != "" {
+				t.Fatalf("unexpected netip.Prefix (-want +got):\n%s", diff)
 			}
 
-			// Child subnet with a matching subnet ID should always reside
-			// within (or be equal to for /64) their parent.
-			child := tt.p.Subnet(tt.p.SubnetID).IPNet()
-			if !tt.ipn.Contains(child.IP) {
-				t.Fatalf("parent prefix %q does not contain child prefix %q", tt.ipn, child)
+			p, err := FromNetipPrefix(np)
+			if err != nil {
+				t.Fatalf("failed to convert netip.Prefix: %v", err)
 			}
 
-			// For /64s exclusively, a different subnet ID produces a
-			// non-overlapping sibling /64 prefix.
-			if ones, _ := tt.ipn.Mask.Size(); ones != 48 {
-				sibling := tt.p.Subnet(tt.p.SubnetID + 1).IPNet()
-				if child.Contains(sibling.IP) {
-					t.Fatalf("child prefix %q contains sibling prefix %q", child, sibling)
-				}
+			if diff := cmp.Diff(tt.p, p, cmp.AllowUnexported(Prefix{})); diff != "" {
+				t.Fatalf("unexpected round trip Prefix (-want +got):\n%s", diff)
+			}
+
+			// FromNetipPrefix and Parse must agree exactly.
+			pp, err := Parse(tt.s)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if diff := cmp.Diff(pp, p, cmp.AllowUnexported(Prefix{})); diff != "" {
+				t.Fatalf("unexpected parsed Prefix (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestParse(t *testing.T) {
+func TestFromNetipPrefixErrors(t *testing.T) {
 	tests := []struct {
 		name string
-		s    string
-		ok   bool
+		p    netip.Prefix
 	}{
 		{
-			name: "bad",
-			s:    "foo",
+			name: "zero",
 		},
 		{
 			name: "IPv4",
-			s:    "192.0.2.0/24",
-		},
-		{
-			name: "individual IP",
-			s:    "fd00::1/64",
-		},
-		{
-			name: "global unicast prefix",
-			s:    "2001:db8::/32",
+			p:    netip.MustParsePrefix("192.0.2.0/24"),
 		},
 		{
-			name: "wrong subnet size",
-			s:    "2001:db8::/56",
+			name: "not ULA",
+			p:    netip.MustParsePrefix("2001:db8::/48"),
 		},
 		{
-			name: "local false /48",
-			s:    "fc01::/48",
-			ok:   true,
+			name: "/40",
+			p:    netip.MustParsePrefix("fd00::/40"),
 		},
 		{
-			name: "local true /48",
-			s:    "fd02::/48",
-			ok:   true,
+			name: "host bits",
+			p:    netip.MustParsePrefix("fd00:0:0:1::/48"),
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromNetipPrefix(tt.p); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
+
+func TestPrefixString(t *testing.T) {
+	tests := []struct {
+		name            string
+		p               *Prefix
+		short, expanded string
+	}{
 		{
-			name: "local false /64",
-			s:    "fc03:0:0:1010::/64",
-			ok:   true,
+			name: "/48",
+			p: &Prefix{
+				Local:    true,
+				GlobalID: [5]byte{0x00, 0x01, 0x02, 0x03, 0x04},
+			},
+			short:    "fd00:102:304::/48",
+			expanded: "fd00:0102:0304:0000:0000:0000:0000:0000/48",
 		},
 		{
-			name: "local true /64",
-			s:    "fd04:0:0:2020::/64",
-			ok:   true,
+			name: "/64",
+			p: &Prefix{
+				GlobalID: [5]byte{0xab, 0xcd, 0xef, 0x01, 0x23},
+				SubnetID: 0x000f,
+			},
+			short:    "fcab:cdef:123:f::/64",
+			expanded: "fcab:cdef:0123:000f:0000:0000:0000:0000/64",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p, err := Parse(tt.s)
-			if tt.ok && err != nil {
-				t.Fatalf("failed to parse: %v", err)
+			if diff := cmp.Diff(tt.short, tt.p.String()); diff != "" {
+				t.Fatalf("unexpected short string (-want +got):\n%s", diff)
 			}
-			if !tt.ok && err == nil {
-				t.Fatal("expected an error, but none occurred")
+
+			if diff := cmp.Diff(tt.expanded, tt.p.StringExpanded()); diff != "" {
+				t.Fatalf("unexpected expanded string (-want +got):\n%s", diff)
 			}
+
+			// Both forms must parse to the same Prefix.
+			p, err := Parse(tt.p.StringExpanded())
 			if err != nil {
-				t.Logf("err: %v", err)
-				return
+				t.Fatalf("failed to parse expanded string: %v", err)
 			}
 
-			if diff := cmp.Diff(tt.s, p.String()); diff != "" {
-				t.Fatalf("unexpected Prefix string (-want +got):\n%s", diff)
+			if diff := cmp.Diff(tt.short, p.String()); diff != "" {
+				t.Fatalf("unexpected parsed string (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
 
+func mustParse(s string) *Prefix {
+	p, err := Parse(s)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse prefix: %v", err))
+	}
+
+	return p
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse CIDR: %v", err))
+	}
+
+	return n
+}
+
 func testPrefixes(t *testing.T, want, got *Prefix, parent *net.IPNet) {
 	t.Helper()
 
@@ -325,3 +2037,39 @@ func testPrefixes(t *testing.T, want, got *Prefix, parent *net.IPNet) {
 		}
 	}
 }
+
+// ExampleFormat demonstrates retrieving the fields of a /48 Prefix and one of
+// its /64 subnets in their string forms.
+func ExampleFormat() {
+	p, err := Parse("fd5a:5c39:fc1::/48")
+	if err != nil {
+		log.Fatalf("failed to parse prefix: %v", err)
+	}
+
+	for _, p := range []*Prefix{p, p.Subnet(1)} {
+		local, globalID, subnetID, prefixLen := Format(p)
+		fmt.Println(local, globalID, subnetID, prefixLen)
+	}
+
+	// Output:
+	// true 0x5a5c390fc1 0x0000 48
+	// true 0x5a5c390fc1 0x0001 64
+}
+
+// ExampleGenerator demonstrates reproducible Prefix generation with a fixed
+// time of day and MAC address seed.
+func ExampleGenerator() {
+	g := &Generator{
+		Now: func() time.Time { return time.Unix(1, 0) },
+	}
+
+	p, err := g.Generate(net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad})
+	if err != nil {
+		log.Fatalf("failed to generate prefix: %v", err)
+	}
+
+	fmt.Println(p)
+
+	// Output:
+	// fd5a:5c39:fc1::/48
+}
@@ -0,0 +1,51 @@
+// Package rfc4193yaml provides YAML encoding and decoding of rfc4193.Prefix
+// values using gopkg.in/yaml.v3.
+//
+// This package is separate from package rfc4193 so that users of rfc4193 do
+// not depend on the YAML libraries.
+package rfc4193yaml
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/netx/rfc4193"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ yaml.Marshaler   = Prefix{}
+	_ yaml.Unmarshaler = &Prefix{}
+)
+
+// A Prefix is a yaml.Marshaler and yaml.Unmarshaler which encodes an
+// rfc4193.Prefix as a CIDR notation string scalar, such as "fd00::/48", for
+// use as a field in a YAML configuration structure. Prefix is nil if the field
+// is absent or null.
+type Prefix struct {
+	Prefix *rfc4193.Prefix
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (p Prefix) MarshalYAML() (interface{}, error) {
+	if p.Prefix == nil {
+		return nil, nil
+	}
+
+	return p.Prefix.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. The node must be a string scalar
+// which is accepted by rfc4193.Parse, or an error is returned.
+func (p *Prefix) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+		return fmt.Errorf("rfc4193yaml: line %d: prefix must be a string", node.Line)
+	}
+
+	prefix, err := rfc4193.Parse(node.Value)
+	if err != nil {
+		return fmt.Errorf("rfc4193yaml: line %d: %w", node.Line, err)
+	}
+
+	p.Prefix = prefix
+	return nil
+}
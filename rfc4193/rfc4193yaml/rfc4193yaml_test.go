@@ -0,0 +1,123 @@
+package rfc4193yaml_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netx/rfc4193/rfc4193yaml"
+	"gopkg.in/yaml.v3"
+)
+
+type config struct {
+	Prefix rfc4193yaml.Prefix `yaml:"prefix"`
+}
+
+func TestPrefixUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		s    string
+		ok   bool
+	}{
+		{
+			name: "absent",
+			in:   "{}",
+			ok:   true,
+		},
+		{
+			name: "null",
+			in:   "prefix: null",
+			ok:   true,
+		},
+		{
+			name: "not ULA",
+			in:   "prefix: 2001:db8::/48",
+		},
+		{
+			name: "not CIDR",
+			in:   "prefix: fd00::",
+		},
+		{
+			name: "integer",
+			in:   "prefix: 48",
+		},
+		{
+			name: "sequence",
+			in:   "prefix: [fd00::/48]",
+		},
+		{
+			name: "OK /48",
+			in:   "prefix: fd00::/48",
+			s:    "fd00::/48",
+			ok:   true,
+		},
+		{
+			name: "OK quoted /64",
+			in:   `prefix: "fd00:0:0:1::/64"`,
+			s:    "fd00:0:0:1::/64",
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c config
+			err := yaml.Unmarshal([]byte(tt.in), &c)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				return
+			}
+
+			var s string
+			if c.Prefix.Prefix != nil {
+				s = c.Prefix.Prefix.String()
+			}
+
+			if diff := cmp.Diff(tt.s, s); diff != "" {
+				t.Fatalf("unexpected prefix (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrefixMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "null",
+			in:   "prefix: null",
+			out:  "prefix: null\n",
+		},
+		{
+			name: "OK",
+			in:   "prefix: fd00:0:0:1::/64",
+			out:  "prefix: fd00:0:0:1::/64\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c config
+			if err := yaml.Unmarshal([]byte(tt.in), &c); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+
+			b, err := yaml.Marshal(c)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.out, string(b)); diff != "" {
+				t.Fatalf("unexpected YAML (-want +got):\n%s", diff)
+			}
+		})
+	}
+}